@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -50,8 +52,13 @@ func fauxTopLevelCommandForHelpAndUsage() *cobra.Command {
 	// Adjust the version command like we do for the real command hierarchy.
 	adjustVersionCommand(root)
 
-	// Add the legal command like we do for the real command hierarchy.
+	// Add Mutagen Compose-specific commands like we do for the real command
+	// hierarchy.
 	root.AddCommand(legalCommand)
+	root.AddCommand(mutagenCommand)
+	root.AddCommand(syncCommand)
+	root.AddCommand(forwardCommand)
+	root.AddCommand(monitorCommand)
 
 	// HACK: Set this command up as a Docker plugin root command in order to add
 	// the top-level Docker CLI flags and to set usage formatting. Normally
@@ -87,6 +94,15 @@ func fauxTopLevelCommandForHelpAndUsage() *cobra.Command {
 // to display usage information that corresponds to Mutagen Compose.
 func adjustUsageInformation(cmd *cobra.Command) {
 	cmd.SetUsageFunc(func(c *cobra.Command) error {
+		// Identify the top-level ancestor of the command (which may be the
+		// command itself). We have to do this before creating the faux
+		// top-level command because Mutagen Compose-specific commands are
+		// reparented onto it when it's created.
+		topLevel := c
+		for topLevel.HasParent() && topLevel.Parent() != cmd {
+			topLevel = topLevel.Parent()
+		}
+
 		// Create a faux top-level command with proper usage information,
 		// including merged-in top-level Docker CLI flags that we support.
 		faux := fauxTopLevelCommandForHelpAndUsage()
@@ -98,9 +114,10 @@ func adjustUsageInformation(cmd *cobra.Command) {
 		}
 
 		// Otherwise, this is a help request for a Compose subcommand, so
-		// reparent the subcommand onto the faux top-level command to get a
-		// proper command name and then display its usage.
-		faux.AddCommand(c)
+		// reparent the subcommand (or, for nested subcommands, its top-level
+		// ancestor) onto the faux top-level command to get a proper command
+		// name and then display its usage.
+		faux.AddCommand(topLevel)
 		return c.Usage()
 	})
 }
@@ -184,3 +201,32 @@ func adjustVersionCommand(cmd *cobra.Command) {
 		return nil
 	}
 }
+
+// adjustInterruptHandling adjusts the specified Mutagen Compose-specific
+// commands (and their subcommands) so that interrupt and termination signals
+// cancel the command context (rather than terminating the process), allowing
+// operations to abort cleanly and report the state in which they left
+// sessions. Interrupted commands exit with the same status code as interrupted
+// Compose commands.
+func adjustInterruptHandling(commands ...*cobra.Command) {
+	for _, c := range commands {
+		if run := c.RunE; run != nil {
+			c.RunE = func(command *cobra.Command, arguments []string) error {
+				ctx, cancel := signal.NotifyContext(command.Context(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+				command.SetContext(ctx)
+				if err := run(command, arguments); err != nil {
+					if ctx.Err() != nil {
+						return cli.StatusError{
+							StatusCode: 130,
+							Status:     err.Error(),
+						}
+					}
+					return err
+				}
+				return nil
+			}
+		}
+		adjustInterruptHandling(c.Commands()...)
+	}
+}
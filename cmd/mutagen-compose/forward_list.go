@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// forwardListMain is the entry point for the list command.
+func forwardListMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.ListForwardingSessions(command.Context(), name, arguments, forwardListConfiguration.long)
+}
+
+// forwardListCommand is the list command.
+var forwardListCommand = &cobra.Command{
+	Use:          "list [<name>...]",
+	Short:        "List the project's forwarding sessions and their statuses",
+	RunE:         forwardListMain,
+	SilenceUsage: true,
+}
+
+// forwardListConfiguration stores configuration for the list command.
+var forwardListConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// long indicates whether or not to use long-format listing.
+	long bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := forwardListCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&forwardListConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up list flags.
+	flags.BoolVarP(&forwardListConfiguration.long, "long", "l", false, "Show detailed session information")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// forwardPauseMain is the entry point for the pause command.
+func forwardPauseMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.PauseForwardingSessions(command.Context(), name, arguments)
+}
+
+// forwardPauseCommand is the pause command.
+var forwardPauseCommand = &cobra.Command{
+	Use:          "pause [<name>...]",
+	Short:        "Pause the project's forwarding sessions",
+	RunE:         forwardPauseMain,
+	SilenceUsage: true,
+}
+
+// forwardPauseConfiguration stores configuration for the pause command.
+var forwardPauseConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := forwardPauseCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&forwardPauseConfiguration.help, "help", "h", false, "Show help information")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// forwardRecreateMain is the entry point for the recreate command.
+func forwardRecreateMain(command *cobra.Command, arguments []string) error {
+	project, err := loadProject(command)
+	if err != nil {
+		return err
+	}
+	return liaison.RecreateForwardingSessions(command.Context(), project, arguments)
+}
+
+// forwardRecreateCommand is the recreate command.
+var forwardRecreateCommand = &cobra.Command{
+	Use:          "recreate [<name>...]",
+	Short:        "Recreate the project's forwarding sessions from their current definitions",
+	RunE:         forwardRecreateMain,
+	SilenceUsage: true,
+}
+
+// forwardRecreateConfiguration stores configuration for the recreate command.
+var forwardRecreateConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := forwardRecreateCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&forwardRecreateConfiguration.help, "help", "h", false, "Show help information")
+}
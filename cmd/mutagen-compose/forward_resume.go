@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// forwardResumeMain is the entry point for the resume command.
+func forwardResumeMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.ResumeForwardingSessions(command.Context(), name, arguments)
+}
+
+// forwardResumeCommand is the resume command.
+var forwardResumeCommand = &cobra.Command{
+	Use:          "resume [<name>...]",
+	Short:        "Resume the project's forwarding sessions",
+	RunE:         forwardResumeMain,
+	SilenceUsage: true,
+}
+
+// forwardResumeConfiguration stores configuration for the resume command.
+var forwardResumeConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := forwardResumeCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&forwardResumeConfiguration.help, "help", "h", false, "Show help information")
+}
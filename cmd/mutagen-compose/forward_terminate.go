@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// forwardTerminateMain is the entry point for the terminate command.
+func forwardTerminateMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.TerminateForwardingSessions(command.Context(), name, arguments)
+}
+
+// forwardTerminateCommand is the terminate command.
+var forwardTerminateCommand = &cobra.Command{
+	Use:          "terminate [<name>...]",
+	Short:        "Terminate the project's forwarding sessions (until the next up)",
+	RunE:         forwardTerminateMain,
+	SilenceUsage: true,
+}
+
+// forwardTerminateConfiguration stores configuration for the terminate command.
+var forwardTerminateConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := forwardTerminateCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&forwardTerminateConfiguration.help, "help", "h", false, "Show help information")
+}
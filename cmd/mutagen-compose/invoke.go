@@ -64,8 +64,14 @@ func invokeCompose(liaison *mutagen.Liaison) {
 		adjustUsageInformation(cmd)
 		adjustUnknownCommandErrors(cmd)
 		adjustVersionCommand(cmd)
+		adjustPsCommand(cmd)
 		cmd.AddCommand(legalCommand)
 		cmd.AddCommand(generateCommand)
+		cmd.AddCommand(mutagenCommand)
+		cmd.AddCommand(syncCommand)
+		cmd.AddCommand(forwardCommand)
+		cmd.AddCommand(monitorCommand)
+		adjustInterruptHandling(mutagenCommand, syncCommand, forwardCommand, monitorCommand)
 		return cmd
 	},
 		manager.Metadata{
@@ -21,6 +21,10 @@ func isCobraShellCompletionCommand(argument string) bool {
 		argument == cobra.ShellCompNoDescRequestCmd
 }
 
+// liaison is the Mutagen liaison for the current invocation. It's shared with
+// Mutagen Compose-specific commands so that they can access Mutagen sessions.
+var liaison = &mutagen.Liaison{}
+
 func init() {
 	// Set flags for invoking Mutagen cmd packages externally.
 	external.UsePathBasedLookupForDaemonStart = true
@@ -104,9 +108,13 @@ func main() {
 	emulatedArgs = append(emulatedArgs, commandAndArguments...)
 	os.Args = emulatedArgs
 
-	// Create the Mutagen liaison.
-	liaison := &mutagen.Liaison{}
-
 	// Invoke Compose.
 	invokeCompose(liaison)
+
+	// Shut down the liaison. In the event of a failure, plugin invocation will
+	// exit directly, in which case process termination is sufficient to close
+	// the liaison's daemon connection.
+	if err := liaison.Shutdown(); err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to shut down Mutagen liaison:", err)
+	}
 }
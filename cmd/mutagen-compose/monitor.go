@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mutagen-io/mutagen-compose/pkg/mutagen"
+)
+
+// ansiEnabled determines whether or not ANSI control sequences should be used
+// for output based on the top-level Compose --ansi flag.
+func ansiEnabled(command *cobra.Command) bool {
+	ansi, _ := composeFlags(command).GetString("ansi")
+	switch ansi {
+	case "never":
+		return false
+	case "always":
+		return true
+	default:
+		return liaison.DockerCLI().Out().IsTerminal()
+	}
+}
+
+// runMonitor performs session monitoring for the monitor commands.
+func runMonitor(command *cobra.Command, arguments []string, forwarding, synchronization bool, format string) error {
+	// Validate the output format.
+	if !(format == "" || format == "table" || format == "json") {
+		return errors.New("unsupported format (must be \"table\" or \"json\")")
+	}
+
+	// Resolve the project name.
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+
+	// Monitor until interrupted.
+	ctx, cancel := signal.NotifyContext(command.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return liaison.MonitorSessions(ctx, name, mutagen.MonitorOptions{
+		Forwarding:      forwarding,
+		Synchronization: synchronization,
+		Names:           arguments,
+		JSON:            format == "json",
+		ANSI:            ansiEnabled(command),
+	}, liaison.DockerCLI().Out())
+}
+
+// monitorMain is the entry point for the monitor command.
+func monitorMain(command *cobra.Command, arguments []string) error {
+	return runMonitor(command, arguments, true, true, monitorConfiguration.format)
+}
+
+// monitorCommand is the monitor command.
+var monitorCommand = &cobra.Command{
+	Use:          "monitor [<name>...]",
+	Short:        "Show a live display of the project's Mutagen sessions",
+	RunE:         monitorMain,
+	SilenceUsage: true,
+}
+
+// monitorConfiguration stores configuration for the monitor command.
+var monitorConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// format is the output format.
+	format string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := monitorCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&monitorConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up formatting flags.
+	flags.StringVar(&monitorConfiguration.format, "format", "table", "Format the output (table|json)")
+}
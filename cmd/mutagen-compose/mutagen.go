@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// mutagenMain is the entry point for the mutagen command.
+func mutagenMain(command *cobra.Command, _ []string) error {
+	// If no commands were given, then print help information and bail. We don't
+	// have to worry about warning about arguments being present here (which
+	// would be incorrect usage) because arguments can't even reach this point
+	// (they will be mistaken for subcommands and a error will be displayed).
+	command.Help()
+
+	// Success.
+	return nil
+}
+
+// mutagenCommand is the mutagen command.
+var mutagenCommand = &cobra.Command{
+	Use:          "mutagen",
+	Short:        "Manage Mutagen Compose infrastructure",
+	RunE:         mutagenMain,
+	SilenceUsage: true,
+}
+
+// mutagenConfiguration stores configuration for the mutagen command.
+var mutagenConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := mutagenCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&mutagenConfiguration.help, "help", "h", false, "Show help information")
+
+	// Register commands.
+	mutagenCommand.AddCommand(
+		mutagenPruneCommand,
+		mutagenMetricsCommand,
+	)
+}
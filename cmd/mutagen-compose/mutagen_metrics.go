@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mutagen-io/mutagen/cmd"
+)
+
+// mutagenMetricsMain is the entry point for the metrics command.
+func mutagenMetricsMain(command *cobra.Command, _ []string) error {
+	// Resolve the project name.
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+
+	// Serve metrics until interrupted.
+	ctx, cancel := signal.NotifyContext(command.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return liaison.ServeMetrics(ctx, name, mutagenMetricsConfiguration.listen, liaison.DockerCLI().Err())
+}
+
+// mutagenMetricsCommand is the metrics command.
+var mutagenMetricsCommand = &cobra.Command{
+	Use:          "metrics",
+	Short:        "Serve Prometheus metrics for the project's Mutagen sessions",
+	Args:         cmd.DisallowArguments,
+	RunE:         mutagenMetricsMain,
+	SilenceUsage: true,
+}
+
+// mutagenMetricsConfiguration stores configuration for the metrics command.
+var mutagenMetricsConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// listen is the address on which to serve metrics.
+	listen string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := mutagenMetricsCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&mutagenMetricsConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up listening flags.
+	flags.StringVar(&mutagenMetricsConfiguration.listen, "listen", "127.0.0.1:9750", "Specify the address on which to serve metrics")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mutagen-io/mutagen/cmd"
+)
+
+// mutagenPruneMain is the entry point for the prune command.
+func mutagenPruneMain(command *cobra.Command, _ []string) error {
+	return liaison.PruneSessions(command.Context())
+}
+
+// mutagenPruneCommand is the prune command.
+var mutagenPruneCommand = &cobra.Command{
+	Use:          "prune",
+	Short:        "Terminate Mutagen sessions whose sidecar container no longer exists",
+	Args:         cmd.DisallowArguments,
+	RunE:         mutagenPruneMain,
+	SilenceUsage: true,
+}
+
+// mutagenPruneConfiguration stores configuration for the prune command.
+var mutagenPruneConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := mutagenPruneCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&mutagenPruneConfiguration.help, "help", "h", false, "Show help information")
+}
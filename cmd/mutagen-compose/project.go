@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/compose-spec/compose-go/cli"
+	"github.com/compose-spec/compose-go/types"
+
+	commands "github.com/docker/compose/v2/cmd/compose"
+)
+
+// composeFlags returns the flag set of the Compose root command (which isn't
+// the overall root command when running via the plugin infrastructure). These
+// are the top-level Compose flags.
+func composeFlags(command *cobra.Command) *pflag.FlagSet {
+	for command.HasParent() && command.Flags().Lookup("project-name") == nil {
+		command = command.Parent()
+	}
+	return command.Flags()
+}
+
+// projectOptions reconstructs the Compose project options from the top-level
+// Compose flags. Compose binds these flags to storage that isn't accessible
+// outside of its root command, so we read them back from the flag set.
+func projectOptions(command *cobra.Command) *commands.ProjectOptions {
+	flags := composeFlags(command)
+	options := &commands.ProjectOptions{}
+	options.ProjectName, _ = flags.GetString("project-name")
+	options.Profiles, _ = flags.GetStringArray("profile")
+	options.ConfigPaths, _ = flags.GetStringArray("file")
+	options.EnvFiles, _ = flags.GetStringArray("env-file")
+	options.ProjectDir, _ = flags.GetString("project-directory")
+	options.WorkDir, _ = flags.GetString("workdir")
+	options.Compatibility, _ = flags.GetBool("compatibility")
+	return options
+}
+
+// projectOrName loads the Compose project specified by the top-level Compose
+// flags, falling back to just the project name if the project can't be loaded
+// but its name is known. It mirrors the behavior of project resolution for
+// Compose commands (such as ps) that don't require a project definition.
+func projectOrName(command *cobra.Command) (*types.Project, string, error) {
+	options := projectOptions(command)
+	name := options.ProjectName
+	var project *types.Project
+	if len(options.ConfigPaths) > 0 || options.ProjectName == "" {
+		p, err := options.ToProject(liaison.DockerCLI(), nil, cli.WithDiscardEnvFile)
+		if err != nil {
+			if envProjectName := os.Getenv(commands.ComposeProjectName); envProjectName != "" {
+				return nil, envProjectName, nil
+			}
+			return nil, "", err
+		}
+		project = p
+		name = p.Name
+	}
+	return project, name, nil
+}
+
+// projectName resolves the name of the Compose project specified by the
+// top-level Compose flags.
+func projectName(command *cobra.Command) (string, error) {
+	_, name, err := projectOrName(command)
+	return name, err
+}
+
+// loadProject loads the Compose project specified by the top-level Compose
+// flags. Unlike projectOrName, it requires that the project definition be
+// loadable.
+func loadProject(command *cobra.Command) (*types.Project, error) {
+	return projectOptions(command).ToProject(liaison.DockerCLI(), nil)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/cmd/formatter"
+
+	"github.com/mutagen-io/mutagen-compose/pkg/mutagen"
+)
+
+// sidecarContainerIDMatcher matches full container identifiers in formatted
+// Docker URLs so that they can be truncated for display.
+var sidecarContainerIDMatcher = regexp.MustCompile(`docker://([0-9a-f]{12})[0-9a-f]{52}`)
+
+// psSessionsJSON is the JSON representation of Mutagen sessions in ps output.
+type psSessionsJSON struct {
+	// MutagenSessions are the Mutagen sessions for the project.
+	MutagenSessions []mutagen.SessionSummary `json:"mutagenSessions"`
+}
+
+// formatSessionEndpoints formats session endpoints for tabular display.
+func formatSessionEndpoints(session mutagen.SessionSummary, truncate bool) string {
+	separator := " <-> "
+	if session.Kind == mutagen.SessionKindForwarding {
+		separator = " -> "
+	}
+	endpoints := strings.Join(session.Endpoints, separator)
+	if truncate {
+		endpoints = sidecarContainerIDMatcher.ReplaceAllString(endpoints, "docker://$1")
+	}
+	return endpoints
+}
+
+// adjustPsCommand adjusts the behavior of the ps command to include Mutagen
+// sessions in a manner that respects its formatting flags.
+func adjustPsCommand(cmd *cobra.Command) {
+	// Look up the ps command.
+	ps, _, _ := cmd.Find([]string{"ps"})
+
+	// Extract its flags and add a flag to opt in to session listings in JSON
+	// output. Compose emits JSON output as one container record per line, so
+	// session listings are only appended when explicitly requested in order to
+	// avoid breaking consumers that process that output line-by-line.
+	flags := ps.Flags()
+	flags.Bool("mutagen-sessions", false, "Include Mutagen sessions in JSON output")
+
+	// Extract the original entry point.
+	originalRunE := ps.RunE
+
+	// Override the entry point with one that displays Mutagen sessions after
+	// the container listing.
+	ps.RunE = func(command *cobra.Command, args []string) error {
+		// Perform the container listing.
+		if err := originalRunE(command, args); err != nil {
+			return err
+		}
+
+		// Extract flag values.
+		quiet, _ := flags.GetBool("quiet")
+		services, _ := flags.GetBool("services")
+		format, _ := flags.GetString("format")
+		noTrunc, _ := flags.GetBool("no-trunc")
+		includeInJSON, _ := flags.GetBool("mutagen-sessions")
+		if format == "" {
+			format = liaison.DockerCLI().ConfigFile().PsFormat
+		}
+
+		// Quiet and service listings only display container or service names,
+		// custom templates can't be applied to sessions, and JSON output only
+		// includes sessions on request, so we don't display sessions (or
+		// connect to the Mutagen daemon) in those cases.
+		asJSON := format == formatter.JSON || format == formatter.TemplateLegacyJSON
+		asTable := format == "" || format == formatter.TABLE
+		if quiet || services || !(asJSON && includeInJSON || asTable) {
+			return nil
+		}
+
+		// Resolve the project name.
+		_, projectName, err := projectOrName(command)
+		if err != nil {
+			return err
+		}
+
+		// Query the project's sessions.
+		sessions, err := liaison.ProjectSessions(command.Context(), projectName)
+		if err != nil {
+			return fmt.Errorf("unable to list Mutagen sessions: %w", err)
+		}
+
+		// Print the sessions.
+		out := liaison.DockerCLI().Out()
+		if asJSON {
+			if sessions == nil {
+				sessions = []mutagen.SessionSummary{}
+			}
+			return json.NewEncoder(out).Encode(psSessionsJSON{sessions})
+		} else if len(sessions) == 0 {
+			return nil
+		}
+		fmt.Fprintln(out)
+		writer := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+		fmt.Fprintln(writer, "MUTAGEN SESSION\tKIND\tSTATUS\tENDPOINTS\tCONFLICTS\tPROBLEMS")
+		for _, session := range sessions {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				session.Name, session.Kind, session.Status,
+				formatSessionEndpoints(session, !noTrunc),
+				session.Conflicts, session.Problems,
+			)
+		}
+		return writer.Flush()
+	}
+}
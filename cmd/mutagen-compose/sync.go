@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncMain is the entry point for the sync command.
+func syncMain(command *cobra.Command, _ []string) error {
+	// If no commands were given, then print help information and bail. We don't
+	// have to worry about warning about arguments being present here (which
+	// would be incorrect usage) because arguments can't even reach this point
+	// (they will be mistaken for subcommands and a error will be displayed).
+	command.Help()
+
+	// Success.
+	return nil
+}
+
+// syncCommand is the sync command.
+var syncCommand = &cobra.Command{
+	Use:          "sync",
+	Short:        "Manage the project's Mutagen synchronization sessions",
+	RunE:         syncMain,
+	SilenceUsage: true,
+}
+
+// syncConfiguration stores configuration for the sync command.
+var syncConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncConfiguration.help, "help", "h", false, "Show help information")
+
+	// Register commands.
+	syncCommand.AddCommand(
+		syncListCommand,
+		syncConflictsCommand,
+		syncMonitorCommand,
+		syncFlushCommand,
+		syncPauseCommand,
+		syncResumeCommand,
+		syncResetCommand,
+		syncTerminateCommand,
+		syncPullCommand,
+		syncSnapshotCommand,
+		syncRestoreCommand,
+	)
+}
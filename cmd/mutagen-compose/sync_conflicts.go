@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mutagen-io/mutagen-compose/pkg/mutagen"
+)
+
+// syncConflictsMain is the entry point for the conflicts command.
+func syncConflictsMain(command *cobra.Command, arguments []string) error {
+	// Validate the resolution mode.
+	resolution := syncConflictsConfiguration.resolve
+	if !mutagen.IsValidConflictResolution(resolution) {
+		return errors.New("unsupported resolution mode (must be \"alpha\", \"beta\", or \"prompt\")")
+	}
+
+	// Resolve the project name.
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+
+	// List and (optionally) resolve conflicts.
+	return liaison.SynchronizationConflicts(command.Context(), name, arguments, resolution)
+}
+
+// syncConflictsCommand is the conflicts command.
+var syncConflictsCommand = &cobra.Command{
+	Use:          "conflicts [<name>...]",
+	Short:        "List (and optionally resolve) the project's synchronization conflicts",
+	RunE:         syncConflictsMain,
+	SilenceUsage: true,
+}
+
+// syncConflictsConfiguration stores configuration for the conflicts command.
+var syncConflictsConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// resolve is the conflict resolution mode.
+	resolve string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncConflictsCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncConflictsConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up resolution flags.
+	flags.StringVar(&syncConflictsConfiguration.resolve, "resolve", "", "Resolve conflicts by keeping the specified side (alpha|beta|prompt)")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncFlushMain is the entry point for the flush command.
+func syncFlushMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.FlushSynchronizationSessions(command.Context(), name, arguments)
+}
+
+// syncFlushCommand is the flush command.
+var syncFlushCommand = &cobra.Command{
+	Use:          "flush [<name>...]",
+	Short:        "Flush the project's synchronization sessions",
+	RunE:         syncFlushMain,
+	SilenceUsage: true,
+}
+
+// syncFlushConfiguration stores configuration for the flush command.
+var syncFlushConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncFlushCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncFlushConfiguration.help, "help", "h", false, "Show help information")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncListMain is the entry point for the list command.
+func syncListMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.ListSynchronizationSessions(command.Context(), name, arguments, syncListConfiguration.long)
+}
+
+// syncListCommand is the list command.
+var syncListCommand = &cobra.Command{
+	Use:          "list [<name>...]",
+	Short:        "List the project's synchronization sessions and their statuses",
+	RunE:         syncListMain,
+	SilenceUsage: true,
+}
+
+// syncListConfiguration stores configuration for the list command.
+var syncListConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// long indicates whether or not to use long-format listing.
+	long bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncListCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncListConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up list flags.
+	flags.BoolVarP(&syncListConfiguration.long, "long", "l", false, "Show detailed session information")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncMonitorMain is the entry point for the monitor command.
+func syncMonitorMain(command *cobra.Command, arguments []string) error {
+	return runMonitor(command, arguments, false, true, syncMonitorConfiguration.format)
+}
+
+// syncMonitorCommand is the monitor command.
+var syncMonitorCommand = &cobra.Command{
+	Use:          "monitor [<name>...]",
+	Short:        "Show a live display of the project's synchronization sessions",
+	RunE:         syncMonitorMain,
+	SilenceUsage: true,
+}
+
+// syncMonitorConfiguration stores configuration for the monitor command.
+var syncMonitorConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// format is the output format.
+	format string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncMonitorCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncMonitorConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up formatting flags.
+	flags.StringVar(&syncMonitorConfiguration.format, "format", "table", "Format the output (table|json)")
+}
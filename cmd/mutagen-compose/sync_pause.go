@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncPauseMain is the entry point for the pause command.
+func syncPauseMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.PauseSynchronizationSessions(command.Context(), name, arguments)
+}
+
+// syncPauseCommand is the pause command.
+var syncPauseCommand = &cobra.Command{
+	Use:          "pause [<name>...]",
+	Short:        "Pause the project's synchronization sessions",
+	RunE:         syncPauseMain,
+	SilenceUsage: true,
+}
+
+// syncPauseConfiguration stores configuration for the pause command.
+var syncPauseConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncPauseCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncPauseConfiguration.help, "help", "h", false, "Show help information")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// syncPullMain is the entry point for the pull command.
+func syncPullMain(command *cobra.Command, arguments []string) error {
+	// Validate arguments.
+	if len(arguments) == 0 {
+		return errors.New("session name required")
+	}
+
+	// Load the project and perform the pull.
+	project, err := loadProject(command)
+	if err != nil {
+		return err
+	}
+	return liaison.PullSynchronizationSession(command.Context(), project, arguments[0], arguments[1:], syncPullConfiguration.force)
+}
+
+// syncPullCommand is the pull command.
+var syncPullCommand = &cobra.Command{
+	Use:   "pull <name> [<path>...]",
+	Short: "Copy content from a oneshot synchronization session's volume back to the host",
+	Long: `Copy content from a oneshot synchronization session's volume back to the host.
+
+If paths (relative to the synchronization root) are specified, then only those
+paths are copied, otherwise the entire synchronization root is copied.
+
+By default, local files that don't exist in the volume are left in place, as
+are local files that have been modified such that they conflict with the
+volume content. With --force, the targeted local content is replaced with an
+exact copy of the volume content, which deletes local files that don't exist
+in the volume and overwrites local modifications.`,
+	RunE:         syncPullMain,
+	SilenceUsage: true,
+}
+
+// syncPullConfiguration stores configuration for the pull command.
+var syncPullConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// force indicates whether or not local content should be replaced with an
+	// exact copy of the volume content.
+	force bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncPullCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncPullConfiguration.help, "help", "h", false, "Show help information")
+	flags.BoolVar(&syncPullConfiguration.force, "force", false, "Replace local content with an exact copy of the volume content, deleting local files that don't exist in the volume")
+}
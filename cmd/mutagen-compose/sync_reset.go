@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncResetMain is the entry point for the reset command.
+func syncResetMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.ResetSynchronizationSessions(command.Context(), name, arguments)
+}
+
+// syncResetCommand is the reset command.
+var syncResetCommand = &cobra.Command{
+	Use:          "reset [<name>...]",
+	Short:        "Reset synchronization history for the project's synchronization sessions",
+	RunE:         syncResetMain,
+	SilenceUsage: true,
+}
+
+// syncResetConfiguration stores configuration for the reset command.
+var syncResetConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncResetCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncResetConfiguration.help, "help", "h", false, "Show help information")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// syncRestoreMain is the entry point for the restore command.
+func syncRestoreMain(command *cobra.Command, arguments []string) error {
+	// Validate arguments.
+	if len(arguments) != 1 {
+		return errors.New("a single session name must be specified")
+	}
+
+	// Resolve the project name.
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+
+	// Determine the input source. If we're reading from standard input, then
+	// refuse to read from a terminal, just like docker load.
+	var in io.Reader
+	input := syncRestoreConfiguration.input
+	if input == "" || input == "-" {
+		if liaison.DockerCLI().In().IsTerminal() {
+			return errors.New("refusing to read snapshot from a terminal (use -i/--input or redirect)")
+		}
+		in = liaison.DockerCLI().In()
+	} else {
+		file, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("unable to open input file: %w", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	// Perform the restoration.
+	return liaison.RestoreSynchronizationSession(command.Context(), name, arguments[0], in)
+}
+
+// syncRestoreCommand is the restore command.
+var syncRestoreCommand = &cobra.Command{
+	Use:          "restore <name>",
+	Short:        "Replace a synchronization session's volume contents from a tar archive",
+	RunE:         syncRestoreMain,
+	SilenceUsage: true,
+}
+
+// syncRestoreConfiguration stores configuration for the restore command.
+var syncRestoreConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// input is the input file path.
+	input string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncRestoreCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncRestoreConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up input flags.
+	flags.StringVarP(&syncRestoreConfiguration.input, "input", "i", "", "Read from a file instead of standard input")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncResumeMain is the entry point for the resume command.
+func syncResumeMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.ResumeSynchronizationSessions(command.Context(), name, arguments)
+}
+
+// syncResumeCommand is the resume command.
+var syncResumeCommand = &cobra.Command{
+	Use:          "resume [<name>...]",
+	Short:        "Resume the project's synchronization sessions",
+	RunE:         syncResumeMain,
+	SilenceUsage: true,
+}
+
+// syncResumeConfiguration stores configuration for the resume command.
+var syncResumeConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncResumeCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncResumeConfiguration.help, "help", "h", false, "Show help information")
+}
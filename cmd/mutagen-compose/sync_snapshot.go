@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// syncSnapshotMain is the entry point for the snapshot command.
+func syncSnapshotMain(command *cobra.Command, arguments []string) (err error) {
+	// Validate arguments.
+	if len(arguments) != 1 {
+		return errors.New("a single session name must be specified")
+	}
+
+	// Resolve the project name.
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+
+	// Determine the output destination. If we're writing to standard output,
+	// then refuse to write to a terminal, just like docker save.
+	var out io.Writer
+	output := syncSnapshotConfiguration.output
+	if output == "" || output == "-" {
+		if liaison.DockerCLI().Out().IsTerminal() {
+			return errors.New("refusing to write snapshot to a terminal (use -o/--output or redirect)")
+		}
+		out = liaison.DockerCLI().Out()
+	} else {
+		file, createErr := os.Create(output)
+		if createErr != nil {
+			return fmt.Errorf("unable to create output file: %w", createErr)
+		}
+		defer func() {
+			file.Close()
+			if err != nil {
+				os.Remove(output)
+			}
+		}()
+		out = file
+	}
+
+	// Perform the snapshot. If writing to a file fails, then the partial file
+	// will be removed.
+	return liaison.SnapshotSynchronizationSession(command.Context(), name, arguments[0], out)
+}
+
+// syncSnapshotCommand is the snapshot command.
+var syncSnapshotCommand = &cobra.Command{
+	Use:          "snapshot <name>",
+	Short:        "Write a tar archive of a synchronization session's volume contents",
+	RunE:         syncSnapshotMain,
+	SilenceUsage: true,
+}
+
+// syncSnapshotConfiguration stores configuration for the snapshot command.
+var syncSnapshotConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+	// output is the output file path.
+	output string
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncSnapshotCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncSnapshotConfiguration.help, "help", "h", false, "Show help information")
+
+	// Wire up output flags.
+	flags.StringVarP(&syncSnapshotConfiguration.output, "output", "o", "", "Write to a file instead of standard output")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncTerminateMain is the entry point for the terminate command.
+func syncTerminateMain(command *cobra.Command, arguments []string) error {
+	name, err := projectName(command)
+	if err != nil {
+		return err
+	}
+	return liaison.TerminateSynchronizationSessions(command.Context(), name, arguments)
+}
+
+// syncTerminateCommand is the terminate command.
+var syncTerminateCommand = &cobra.Command{
+	Use:          "terminate [<name>...]",
+	Short:        "Terminate the project's synchronization sessions (until the next up)",
+	RunE:         syncTerminateMain,
+	SilenceUsage: true,
+}
+
+// syncTerminateConfiguration stores configuration for the terminate command.
+var syncTerminateConfiguration struct {
+	// help indicates whether or not to show help information and exit.
+	help bool
+}
+
+func init() {
+	// Grab a handle for the command line flags.
+	flags := syncTerminateCommand.Flags()
+
+	// Disable alphabetical sorting of flags in help output.
+	flags.SortFlags = false
+
+	// Manually add a help flag to override the default message. Cobra will
+	// still implement its logic automatically.
+	flags.BoolVarP(&syncTerminateConfiguration.help, "help", "h", false, "Show help information")
+}
@@ -0,0 +1,348 @@
+package mutagen
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
+)
+
+// sessionActivity describes a notable change in a Mutagen session's state.
+type sessionActivity struct {
+	// timestamp is the time at which the change was observed.
+	timestamp time.Time
+	// session is the session state after the change (or the last known state
+	// if the session was terminated).
+	session SessionSummary
+	// action is a short name for the change (e.g. "connect").
+	action string
+	// attributes are additional action-specific attributes.
+	attributes map[string]string
+}
+
+// event converts the activity to a Compose event. Events are attributed to the
+// Mutagen Compose sidecar service and identified by the session identifier.
+func (a sessionActivity) event() api.Event {
+	attributes := map[string]string{
+		"name": a.session.Name,
+		"kind": a.session.Kind,
+	}
+	for key, value := range a.attributes {
+		attributes[key] = value
+	}
+	return api.Event{
+		Timestamp:  a.timestamp,
+		Service:    sidecarServiceName,
+		Container:  a.session.Identifier,
+		Status:     a.action,
+		Attributes: attributes,
+	}
+}
+
+// description returns a human-readable description of the activity.
+func (a sessionActivity) description() string {
+	var description string
+	switch a.action {
+	case "create":
+		description = "session created"
+	case "destroy":
+		description = "session terminated"
+	case "pause":
+		description = "session paused"
+	case "unpause":
+		description = "session resumed"
+	case "connect":
+		description = fmt.Sprintf("connected to %s", a.attributes["endpoint"])
+	case "disconnect":
+		description = fmt.Sprintf("disconnected from %s", a.attributes["endpoint"])
+	case "scan":
+		description = "scanning for changes"
+	case "cycle":
+		description = fmt.Sprintf("synchronization cycle completed (%s total)", a.attributes["cycles"])
+	case "conflicts":
+		if a.session.Conflicts == 0 {
+			description = "all conflicts resolved"
+		} else {
+			description = fmt.Sprintf("%d conflict(s) present", a.session.Conflicts)
+		}
+	case "problems":
+		if a.session.Problems == 0 {
+			description = "all problems resolved"
+		} else {
+			description = fmt.Sprintf("%d problem(s) present", a.session.Problems)
+		}
+	case "error":
+		description = "error: " + a.attributes["error"]
+	default:
+		description = a.action
+	}
+	return fmt.Sprintf("%s (%s): %s", a.session.Name, a.session.Kind, description)
+}
+
+// sessionEndpointNames returns the names of session endpoints (in the order
+// used by SessionSummary) for the specified session kind.
+func sessionEndpointNames(kind string) []string {
+	if kind == SessionKindForwarding {
+		return []string{"source", "destination"}
+	}
+	return []string{"alpha", "beta"}
+}
+
+// diffSessionActivity computes the notable changes between two states of a
+// session. A nil previous state indicates session creation and a nil current
+// state indicates session termination.
+func diffSessionActivity(previous, current *SessionSummary, timestamp time.Time) []sessionActivity {
+	// Handle creation and termination.
+	if previous == nil {
+		return []sessionActivity{{timestamp: timestamp, session: *current, action: "create"}}
+	} else if current == nil {
+		return []sessionActivity{{timestamp: timestamp, session: *previous, action: "destroy"}}
+	}
+
+	// Create a convenience function to record activity.
+	var activity []sessionActivity
+	record := func(action string, attributes map[string]string) {
+		activity = append(activity, sessionActivity{
+			timestamp:  timestamp,
+			session:    *current,
+			action:     action,
+			attributes: attributes,
+		})
+	}
+
+	// Check for pausing and resumption.
+	if previous.Status != pausedStatusDescription && current.Status == pausedStatusDescription {
+		record("pause", nil)
+	} else if previous.Status == pausedStatusDescription && current.Status != pausedStatusDescription {
+		record("unpause", nil)
+	}
+
+	// Check for connectivity changes.
+	for e, endpoint := range sessionEndpointNames(current.Kind) {
+		if e >= len(previous.Connected) || e >= len(current.Connected) {
+			break
+		} else if !previous.Connected[e] && current.Connected[e] {
+			record("connect", map[string]string{"endpoint": endpoint})
+		} else if previous.Connected[e] && !current.Connected[e] {
+			record("disconnect", map[string]string{"endpoint": endpoint})
+		}
+	}
+
+	// Check for the start of scanning.
+	if scanning := synchronization.Status_Scanning.Description(); current.Status == scanning && previous.Status != scanning {
+		record("scan", nil)
+	}
+
+	// Check for completed synchronization cycles.
+	if current.SuccessfulCycles > previous.SuccessfulCycles {
+		record("cycle", map[string]string{"cycles": strconv.FormatUint(current.SuccessfulCycles, 10)})
+	}
+
+	// Check for conflict and problem count changes.
+	if current.Conflicts != previous.Conflicts {
+		record("conflicts", map[string]string{"conflicts": strconv.FormatUint(current.Conflicts, 10)})
+	}
+	if current.Problems != previous.Problems {
+		record("problems", map[string]string{"problems": strconv.FormatUint(current.Problems, 10)})
+	}
+
+	// Check for new errors.
+	if current.LastError != "" && current.LastError != previous.LastError {
+		record("error", map[string]string{"error": current.LastError})
+	}
+
+	// Done.
+	return activity
+}
+
+// watchSessionActivity watches the specified project's Mutagen sessions for
+// notable state changes and invokes the handler for each change. Sessions are
+// selected by project labels (rather than by sidecar container) so that
+// watching continues across sidecar container recreation. The initial state of
+// sessions isn't reported as activity. It runs until the context is cancelled
+// (in which case it returns nil) or an error occurs. If the project has no
+// Mutagen Compose sidecar container, then there's nothing to watch and it
+// returns nil immediately.
+func (l *Liaison) watchSessionActivity(ctx context.Context, projectName string, handler func(sessionActivity) error) error {
+	// Look up the sidecar container to identify the project's sessions and to
+	// ensure that we connect to the daemon managing them.
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return err
+	} else if sidecar == nil {
+		return nil
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(nil)
+	if err != nil {
+		return fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Watch for activity.
+	return watchSessionActivityFrom(ctx,
+		forwardingsvc.NewForwardingClient(daemonConnection),
+		synchronizationsvc.NewSynchronizationClient(daemonConnection),
+		sidecarProjectSessionSelection(sidecar.Labels), nil, handler,
+	)
+}
+
+// watchSessionActivityFrom watches the sessions matching the specified selection
+// for notable state changes and invokes the handler for each change. If a
+// snapshot is specified, then changes are reported relative to the snapshot
+// (starting from its state indices, so that no intervening changes are lost),
+// otherwise the state of sessions when watching starts is used as the baseline
+// (and isn't reported as activity). It runs until the context is cancelled (in
+// which case it returns nil) or an error occurs.
+func watchSessionActivityFrom(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	snapshot *sessionSnapshot,
+	handler func(sessionActivity) error,
+) error {
+	// Initialize the baseline states from the snapshot, if any.
+	states := make(map[string]map[string]SessionSummary)
+	var forwardingStateIndex, synchronizationStateIndex uint64
+	if snapshot != nil {
+		states[SessionKindForwarding] = make(map[string]SessionSummary)
+		states[SessionKindSynchronization] = make(map[string]SessionSummary)
+		for _, summary := range snapshot.summaries {
+			states[summary.Kind][summary.Identifier] = summary
+		}
+		forwardingStateIndex = snapshot.forwardingStateIndex
+		synchronizationStateIndex = snapshot.synchronizationStateIndex
+	}
+
+	// Start polling for session state changes and defer polling termination.
+	pollingCtx, pollingCancel := context.WithCancel(ctx)
+	defer pollingCancel()
+	updates := make(chan monitorUpdate)
+	go pollForwardingSessions(pollingCtx, forwardingService, sessionSelection, "", forwardingStateIndex, updates)
+	go pollSynchronizationSessions(pollingCtx, synchronizationService, sessionSelection, "", synchronizationStateIndex, updates)
+
+	// Process updates until cancelled.
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update := <-updates:
+			// Handle errors.
+			if update.err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return update.err
+			}
+
+			// Index the current states.
+			current := make(map[string]SessionSummary, len(update.summaries))
+			for _, summary := range update.summaries {
+				current[summary.Identifier] = summary
+			}
+
+			// If this is the initial state for this session kind, then just
+			// record it.
+			previous, initialized := states[update.kind]
+			states[update.kind] = current
+			if !initialized {
+				continue
+			}
+
+			// Compute and handle activity.
+			timestamp := time.Now()
+			var activity []sessionActivity
+			for _, summary := range update.summaries {
+				if p, ok := previous[summary.Identifier]; ok {
+					activity = append(activity, diffSessionActivity(&p, &summary, timestamp)...)
+				} else {
+					activity = append(activity, diffSessionActivity(nil, &summary, timestamp)...)
+				}
+			}
+			for identifier, summary := range previous {
+				if _, ok := current[identifier]; !ok {
+					activity = append(activity, diffSessionActivity(&summary, nil, timestamp)...)
+				}
+			}
+			for _, a := range activity {
+				if err := handler(a); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// sessionLogName is the container name used for the synthetic Mutagen session
+// log stream.
+const sessionLogName = sidecarServiceName
+
+// logSessionActivity writes a synthetic log stream describing the specified
+// project's Mutagen sessions to a Compose log consumer. It first logs the
+// current state of each session and then, if following, logs session activity
+// until the context is cancelled. Since the Mutagen daemon doesn't record
+// session history, the since, until, and tail options have no effect. If the
+// project has no Mutagen Compose sidecar container, then nothing is logged.
+func (l *Liaison) logSessionActivity(ctx context.Context, projectName string, consumer api.LogConsumer, options api.LogOptions) error {
+	// Check that the project has a sidecar container.
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return err
+	} else if sidecar == nil {
+		return nil
+	}
+
+	// Create a convenience function to emit log lines.
+	log := func(timestamp time.Time, message string, isError bool) {
+		if options.Timestamps {
+			message = timestamp.Format(time.RFC3339Nano) + " " + message
+		}
+		if isError {
+			consumer.Err(sessionLogName, message)
+		} else {
+			consumer.Log(sessionLogName, message)
+		}
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(nil)
+	if err != nil {
+		return fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Create service clients and the session selection criteria.
+	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
+	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
+	sessionSelection := sidecarProjectSessionSelection(sidecar.Labels)
+
+	// Log the current state of each session.
+	snapshot, err := querySessionSnapshot(ctx, forwardingService, synchronizationService, sessionSelection, "")
+	if err != nil {
+		return err
+	}
+	consumer.Register(sessionLogName)
+	now := time.Now()
+	for _, summary := range snapshot.summaries {
+		log(now, formatMonitorLine(summary), false)
+	}
+
+	// If we're not following, then we're done.
+	if !options.Follow {
+		return nil
+	}
+
+	// Log session activity, starting from the snapshot so that no changes
+	// since it was taken are lost.
+	return watchSessionActivityFrom(ctx, forwardingService, synchronizationService, sessionSelection, snapshot, func(activity sessionActivity) error {
+		log(activity.timestamp, activity.description(), activity.action == "error")
+		return nil
+	})
+}
@@ -2,15 +2,14 @@ package mutagen
 
 import (
 	"context"
-	"errors"
 	"fmt"
-
-	moby "github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/filters"
+	"slices"
+	"sync"
 
 	"github.com/compose-spec/compose-go/types"
 
 	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
 )
 
 // appendServiceByCopy appends a service definition to a slice of service
@@ -91,6 +90,10 @@ func (s *composeService) Create(ctx context.Context, project *types.Project, opt
 		return fmt.Errorf("unable to process project: %w", err)
 	}
 
+	// Any removal of the sidecar container during creation is a recreation, so
+	// retain its sessions until they can be replaced.
+	s.liaison.retainSessionsOnRemoval = true
+
 	// Cache the nominal service lists.
 	services := project.Services
 	disabledServices := project.DisabledServices
@@ -135,6 +138,17 @@ func (s *composeService) Start(ctx context.Context, projectName string, options
 	// Track start invocation.
 	s.startInvoked = true
 
+	// If the existing Mutagen Compose sidecar container was created by a
+	// different version of Mutagen Compose, then attempt to upgrade it before
+	// starting it.
+	if drift, err := s.liaison.sidecarDrift(ctx, projectName); err != nil {
+		return fmt.Errorf("unable to check Mutagen Compose sidecar version: %w", err)
+	} else if drift != "" {
+		if err := s.upgradeSidecar(ctx, options.Project, drift); err != nil {
+			return err
+		}
+	}
+
 	// Start the Mutagen Compose sidecar service first. We do this for
 	// consistency with Up and for the flag-related reasons outlined there (the
 	// hidden start progress updates aren't an issue for Start).
@@ -158,6 +172,63 @@ func (s *composeService) Start(ctx context.Context, projectName string, options
 	return s.service.Start(ctx, projectName, options)
 }
 
+// upgradeSidecar recreates an outdated Mutagen Compose sidecar container outside
+// of an up operation. Because the project is processed in order to recreate the
+// sidecar container, Mutagen sessions will be reconciled (rather than simply
+// resumed) when the new sidecar container is started. If project is nil or the
+// project has disabled automatic sidecar upgrades, then a warning is emitted
+// and the outdated sidecar container is left in place.
+func (s *composeService) upgradeSidecar(ctx context.Context, project *types.Project, drift string) error {
+	// If there's no project available, then we can't recreate the sidecar.
+	if project == nil {
+		reportStatus(ctx, s.liaison.dockerCLI.Err(), sidecarStatusEventID, func(status *statusUpdater) {
+			status.warning(fmt.Sprintf("Outdated (%s), run up to upgrade", drift))
+		})
+		return nil
+	}
+
+	// Process Mutagen extensions for the project and check if upgrades have
+	// been disabled.
+	if err := s.liaison.processProject(project); err != nil {
+		return fmt.Errorf("unable to process project: %w", err)
+	} else if s.liaison.sidecarUpgradeDisabled {
+		reportStatus(ctx, s.liaison.dockerCLI.Err(), sidecarStatusEventID, func(status *statusUpdater) {
+			status.warning(fmt.Sprintf("Outdated (%s), automatic upgrade disabled", drift))
+		})
+		return nil
+	}
+	reportStatus(ctx, s.liaison.dockerCLI.Err(), sidecarStatusEventID, func(status *statusUpdater) {
+		status.done(fmt.Sprintf("Recreating for upgrade (%s)", drift))
+	})
+
+	// Cache the nominal service lists.
+	services := project.Services
+	disabledServices := project.DisabledServices
+
+	// Force recreation of the sidecar container. For information about the
+	// construction of CreateOptions here, see Create.
+	project.Services = types.Services{s.liaison.mutagenService}
+	project.DisabledServices = nil
+	mutagenCreateOptions := api.CreateOptions{
+		Services:      []string{sidecarServiceName},
+		IgnoreOrphans: true,
+		Recreate:      api.RecreateForce,
+	}
+	err := s.service.Create(ctx, project, mutagenCreateOptions)
+
+	// Restore the service lists.
+	project.Services = services
+	project.DisabledServices = disabledServices
+
+	// Handle errors.
+	if err != nil {
+		return fmt.Errorf("unable to recreate Mutagen Compose sidecar service: %w", err)
+	}
+
+	// Success.
+	return nil
+}
+
 // Restart implements github.com/docker/compose/v2/pkg/api.Service.Restart.
 func (s *composeService) Restart(ctx context.Context, projectName string, options api.RestartOptions) error {
 	return s.service.Restart(ctx, projectName, options)
@@ -180,6 +251,43 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 		return fmt.Errorf("unable to process project: %w", err)
 	}
 
+	// Any removal of the sidecar container during up is a recreation, so
+	// retain its sessions until they can be replaced.
+	s.liaison.retainSessionsOnRemoval = true
+
+	// Terminate any of the project's Mutagen Compose sessions whose sidecar
+	// containers have been removed outside of Mutagen Compose. Projects that
+	// don't define any sessions are skipped to avoid starting the Mutagen
+	// daemon unnecessarily.
+	if len(s.liaison.forwarding) > 0 || len(s.liaison.synchronization) > 0 {
+		if err := progress.Run(ctx, func(ctx context.Context) error {
+			_, err := s.liaison.pruneOrphanedSessions(ctx, true)
+			return err
+		}, s.liaison.dockerCLI.Err()); err != nil {
+			return fmt.Errorf("unable to prune orphaned Mutagen sessions: %w", err)
+		}
+	}
+
+	// Determine whether or not the existing Mutagen Compose sidecar container
+	// (if any) was created by a different version of Mutagen Compose. If so,
+	// then force its recreation (which will result in session reconciliation
+	// when the new sidecar container starts), unless automatic upgrades have
+	// been disabled. In all other cases, Compose's normal recreation logic
+	// applies.
+	var sidecarRecreate string
+	if drift, err := s.liaison.sidecarDrift(ctx, project.Name); err != nil {
+		return fmt.Errorf("unable to check Mutagen Compose sidecar version: %w", err)
+	} else if drift != "" {
+		reportStatus(ctx, s.liaison.dockerCLI.Err(), sidecarStatusEventID, func(status *statusUpdater) {
+			if s.liaison.sidecarUpgradeDisabled {
+				status.warning(fmt.Sprintf("Outdated (%s), automatic upgrade disabled", drift))
+			} else {
+				status.done(fmt.Sprintf("Recreating for upgrade (%s)", drift))
+				sidecarRecreate = api.RecreateForce
+			}
+		})
+	}
+
 	// Cache the nominal service lists.
 	services := project.Services
 	disabledServices := project.DisabledServices
@@ -228,6 +336,7 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 		Create: api.CreateOptions{
 			Services:      []string{sidecarServiceName},
 			IgnoreOrphans: true,
+			Recreate:      sidecarRecreate,
 		},
 		Start: api.StartOptions{
 			AttachTo: []string{sidecarServiceName},
@@ -293,31 +402,52 @@ func (s *composeService) Down(ctx context.Context, projectName string, options a
 
 // Logs implements github.com/docker/compose/v2/pkg/api.Service.Logs.
 func (s *composeService) Logs(ctx context.Context, projectName string, consumer api.LogConsumer, options api.LogOptions) error {
-	return s.service.Logs(ctx, projectName, consumer, options)
-}
+	// If this is a dry run or Mutagen logs haven't been requested, then just
+	// perform a direct passthrough.
+	if isDryRun(ctx) || (len(options.Services) > 0 && !slices.Contains(options.Services, sidecarServiceName)) {
+		return s.service.Logs(ctx, projectName, consumer, options)
+	}
 
-// Ps implements github.com/docker/compose/v2/pkg/api.Service.Ps.
-func (s *composeService) Ps(ctx context.Context, projectName string, options api.PsOptions) ([]api.ContainerSummary, error) {
-	// Perform a query to identify the Mutagen Compose sidecar container. We
-	// allow it to not exist, but we don't allow multiple matches.
-	containers, err := s.liaison.dockerCLI.Client().ContainerList(ctx, moby.ContainerListOptions{
-		Filters: filters.NewArgs(
-			filters.Arg("label", fmt.Sprintf("%s=%s", api.ProjectLabel, projectName)),
-			filters.Arg("label", fmt.Sprintf("%s=%s", sidecarRoleLabelKey, sidecarRoleLabelValue)),
-		),
-		All: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("unable to query Mutagen sidecar container: %w", err)
-	} else if len(containers) > 1 {
-		return nil, errors.New("multiple Mutagen sidecar containers identified")
-	} else if len(containers) == 1 {
-		if err := s.liaison.listSessions(ctx, containers[0].ID); err != nil {
-			return nil, err
+	// Create a cancellable context to regulate the log streams.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Start both log streams. Compose log consumers are already designed for
+	// concurrent usage since Compose streams container logs concurrently.
+	composeErrors := make(chan error, 1)
+	go func() {
+		composeErrors <- s.service.Logs(ctx, projectName, consumer, options)
+	}()
+	mutagenErrors := make(chan error, 1)
+	go func() {
+		mutagenErrors <- s.liaison.logSessionActivity(ctx, projectName, consumer, options)
+	}()
+
+	// If not following, then wait for both streams to complete.
+	if !options.Follow {
+		err := <-composeErrors
+		if mutagenErr := <-mutagenErrors; err == nil && mutagenErr != nil {
+			err = fmt.Errorf("unable to log Mutagen session activity: %w", mutagenErr)
 		}
+		return err
 	}
 
-	// Invoke the underlying implementation.
+	// Otherwise wait for the container log stream to terminate (at which point
+	// the session activity stream will be cancelled) or for the session
+	// activity stream to fail.
+	select {
+	case err := <-composeErrors:
+		return err
+	case err := <-mutagenErrors:
+		if err != nil {
+			return fmt.Errorf("unable to log Mutagen session activity: %w", err)
+		}
+		return <-composeErrors
+	}
+}
+
+// Ps implements github.com/docker/compose/v2/pkg/api.Service.Ps.
+func (s *composeService) Ps(ctx context.Context, projectName string, options api.PsOptions) ([]api.ContainerSummary, error) {
 	return s.service.Ps(ctx, projectName, options)
 }
 
@@ -406,7 +536,48 @@ func (s *composeService) Top(ctx context.Context, projectName string, services [
 
 // Events implements github.com/docker/compose/v2/pkg/api.Service.Events.
 func (s *composeService) Events(ctx context.Context, projectName string, options api.EventsOptions) error {
-	return s.service.Events(ctx, projectName, options)
+	// If this is a dry run or Mutagen events haven't been requested, then just
+	// perform a direct passthrough.
+	if isDryRun(ctx) || (len(options.Services) > 0 && !slices.Contains(options.Services, sidecarServiceName)) {
+		return s.service.Events(ctx, projectName, options)
+	}
+
+	// Create a cancellable context to regulate the event streams.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Serialize event consumption since events will arrive from both container
+	// events and Mutagen session activity.
+	var consumerLock sync.Mutex
+	consumer := options.Consumer
+	options.Consumer = func(event api.Event) error {
+		consumerLock.Lock()
+		defer consumerLock.Unlock()
+		return consumer(event)
+	}
+
+	// Start both event streams.
+	composeErrors := make(chan error, 1)
+	go func() {
+		composeErrors <- s.service.Events(ctx, projectName, options)
+	}()
+	mutagenErrors := make(chan error, 1)
+	go func() {
+		mutagenErrors <- s.liaison.watchSessionActivity(ctx, projectName, func(activity sessionActivity) error {
+			return options.Consumer(activity.event())
+		})
+	}()
+
+	// Wait for either stream to terminate.
+	select {
+	case err := <-composeErrors:
+		return err
+	case err := <-mutagenErrors:
+		if err != nil {
+			return fmt.Errorf("unable to watch Mutagen sessions: %w", err)
+		}
+		return <-composeErrors
+	}
 }
 
 // Port implements github.com/docker/compose/v2/pkg/api.Service.Port.
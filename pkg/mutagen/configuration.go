@@ -13,6 +13,23 @@ type sidecarConfiguration struct {
 	Restart string `mapstructure:"restart"`
 	// ContainerName is the name given to the sidecar container.
 	ContainerName string `mapstructure:"container_name"`
+	// Upgrade controls whether or not a sidecar container created by a
+	// different version of Mutagen Compose is automatically recreated. Valid
+	// values are "auto" (the default) and "never".
+	Upgrade string `mapstructure:"upgrade"`
+}
+
+// daemonConfiguration encodes Mutagen daemon configuration.
+type daemonConfiguration struct {
+	// DataDirectory is the path to a project-scoped Mutagen data directory. If
+	// specified, then the project's sessions are managed by a dedicated Mutagen
+	// daemon using this data directory. Relative paths are resolved against
+	// the project directory.
+	DataDirectory string `mapstructure:"data_directory"`
+	// OperationTimeout is the maximum duration of individual Mutagen
+	// operations (e.g. session reconciliation or flushing), using Go duration
+	// syntax. If unspecified (or "0"), operations don't time out.
+	OperationTimeout string `mapstructure:"operation_timeout"`
 }
 
 // forwardingConfiguration encodes a forwarding session specification.
@@ -42,6 +59,13 @@ type synchronizationConfiguration struct {
 	ConfigurationAlpha synchronization.Configuration `mapstructure:"configurationAlpha"`
 	// ConfigurationBeta is the beta-specific configuration for the session.
 	ConfigurationBeta synchronization.Configuration `mapstructure:"configurationBeta"`
+	// Seed controls the initial seeding direction used when the session is
+	// first created. Valid values are "alpha", "beta", and "none" (the
+	// default).
+	Seed string `mapstructure:"seed"`
+	// Lifecycle controls the lifecycle of the session. Valid values are
+	// "persistent" (the default) and "oneshot".
+	Lifecycle string `mapstructure:"lifecycle"`
 }
 
 // configuration encodes collections of Mutagen forwarding and synchronization
@@ -49,6 +73,8 @@ type synchronizationConfiguration struct {
 type configuration struct {
 	// Sidecar represents the sidecar service configuration.
 	Sidecar sidecarConfiguration `mapstructure:"sidecar"`
+	// Daemon represents the Mutagen daemon configuration.
+	Daemon daemonConfiguration `mapstructure:"daemon"`
 	// Forwarding represents the forwarding sessions to be created. If a
 	// "defaults" key is present, it is treated as a template upon which other
 	// configurations are layered, thus keeping syntactic compatibility with the
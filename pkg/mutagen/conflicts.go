@@ -0,0 +1,275 @@
+package mutagen
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	"github.com/mutagen-io/mutagen/pkg/platform/terminal"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization/core"
+	"github.com/mutagen-io/mutagen/pkg/url"
+)
+
+const (
+	// ConflictResolutionNone indicates that conflicts should only be listed.
+	ConflictResolutionNone = ""
+	// ConflictResolutionAlpha indicates that conflicts should be resolved in
+	// favor of alpha.
+	ConflictResolutionAlpha = "alpha"
+	// ConflictResolutionBeta indicates that conflicts should be resolved in
+	// favor of beta.
+	ConflictResolutionBeta = "beta"
+	// ConflictResolutionPrompt indicates that the winning endpoint should be
+	// chosen interactively for each conflict.
+	ConflictResolutionPrompt = "prompt"
+)
+
+// IsValidConflictResolution determines whether or not a conflict resolution
+// mode is valid.
+func IsValidConflictResolution(resolution string) bool {
+	switch resolution {
+	case ConflictResolutionNone, ConflictResolutionAlpha, ConflictResolutionBeta, ConflictResolutionPrompt:
+		return true
+	default:
+		return false
+	}
+}
+
+// formatConflictPath formats a conflict path for display.
+func formatConflictPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return terminal.NeutralizeControlCharacters(path)
+}
+
+// formatConflictEntry formats a conflict entry for display.
+func formatConflictEntry(entry *core.Entry) string {
+	if entry == nil {
+		return "<non-existent>"
+	}
+	switch entry.Kind {
+	case core.EntryKind_Directory, core.EntryKind_PhantomDirectory:
+		return "Directory"
+	case core.EntryKind_File:
+		if entry.Executable {
+			return fmt.Sprintf("Executable File (%x)", entry.Digest)
+		}
+		return fmt.Sprintf("File (%x)", entry.Digest)
+	case core.EntryKind_SymbolicLink:
+		return fmt.Sprintf("Symbolic Link (%s)", terminal.NeutralizeControlCharacters(entry.Target))
+	case core.EntryKind_Untracked:
+		return "Untracked content"
+	case core.EntryKind_Problematic:
+		return fmt.Sprintf("Problematic content (%s)", terminal.NeutralizeControlCharacters(entry.Problem))
+	default:
+		return "<unknown>"
+	}
+}
+
+// printConflict prints a synchronization conflict and its changes.
+func printConflict(out io.Writer, index int, conflict *core.Conflict) {
+	fmt.Fprintf(out, "\t[%d] %s\n", index, formatConflictPath(conflict.Root))
+	for _, change := range conflict.AlphaChanges {
+		fmt.Fprintf(out, "\t\t(alpha) %s (%s -> %s)\n",
+			formatConflictPath(change.Path), formatConflictEntry(change.Old), formatConflictEntry(change.New),
+		)
+	}
+	for _, change := range conflict.BetaChanges {
+		fmt.Fprintf(out, "\t\t(beta)  %s (%s -> %s)\n",
+			formatConflictPath(change.Path), formatConflictEntry(change.Old), formatConflictEntry(change.New),
+		)
+	}
+}
+
+// promptConflictWinner prompts for the winning endpoint for a conflict. It
+// returns an empty string if the conflict should be skipped.
+func promptConflictWinner(in *bufio.Reader, out io.Writer) (string, error) {
+	for {
+		fmt.Fprint(out, "\tKeep which side? [a]lpha, [b]eta, [s]kip: ")
+		response, err := in.ReadString('\n')
+		if errors.Is(err, io.EOF) && response == "" {
+			fmt.Fprintln(out)
+			return "", errors.New("input closed before conflict resolution completed")
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("unable to read response: %w", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "a", "alpha":
+			return ConflictResolutionAlpha, nil
+		case "b", "beta":
+			return ConflictResolutionBeta, nil
+		case "s", "skip":
+			return "", nil
+		}
+	}
+}
+
+// removeSidecarContent removes content at the specified path inside the
+// specified sidecar container.
+func (l *Liaison) removeSidecarContent(ctx context.Context, sidecarID, target string) error {
+	// We only support POSIX sidecar containers.
+	if !strings.HasPrefix(target, "/") {
+		return errors.New("content removal is only supported in Linux sidecar containers")
+	}
+
+	// Perform removal.
+	return l.runSidecarCommand(ctx, sidecarID, "rm", "-rf", "--", target)
+}
+
+// removeEndpointContent removes the content at the specified path (relative to
+// the synchronization root) on a synchronization endpoint. Only local endpoints
+// and endpoints inside the project's sidecar container are supported.
+func (l *Liaison) removeEndpointContent(ctx context.Context, endpoint *url.URL, sidecarID, root string) error {
+	if endpoint.Protocol == url.Protocol_Local {
+		return os.RemoveAll(filepath.Join(endpoint.Path, filepath.FromSlash(root)))
+	} else if targetsSidecar(endpoint, sidecarID) {
+		return l.removeSidecarContent(ctx, sidecarID, path.Join(endpoint.Path, root))
+	}
+	return fmt.Errorf("unsupported endpoint (%s)", endpoint.Format(""))
+}
+
+// resolveConflict resolves a synchronization conflict by removing the losing
+// endpoint's content at the conflict root, allowing the winning endpoint's
+// content to propagate when the session is next flushed.
+func (l *Liaison) resolveConflict(ctx context.Context, session *synchronization.Session, sidecarID string, conflict *core.Conflict, winner string) error {
+	// Refuse to remove the entire synchronization root.
+	if conflict.Root == "" {
+		return errors.New("conflicts at the synchronization root must be resolved manually")
+	}
+
+	// Identify the losing endpoint and remove its content.
+	loser, loserName := session.Beta, "beta"
+	if winner == ConflictResolutionBeta {
+		loser, loserName = session.Alpha, "alpha"
+	}
+	if err := l.removeEndpointContent(ctx, loser, sidecarID, conflict.Root); err != nil {
+		return fmt.Errorf("unable to remove content on %s: %w", loserName, err)
+	}
+	return nil
+}
+
+// SynchronizationConflicts lists the conflicts for the named synchronization
+// sessions in the specified project (or all of its synchronization sessions if
+// no names are specified). If a resolution mode other than
+// ConflictResolutionNone is specified, then each conflict is resolved by
+// removing the losing endpoint's content at the conflict root (on the host or
+// inside the sidecar container) while the session is paused, and any affected
+// sessions are then resumed and flushed so that the winning endpoint's content
+// is propagated. This method must only be
+// called after the Docker CLI has been registered.
+func (l *Liaison) SynchronizationConflicts(ctx context.Context, projectName string, names []string, resolution string) error {
+	// Validate the resolution mode.
+	if !IsValidConflictResolution(resolution) {
+		return fmt.Errorf("invalid conflict resolution mode: %s", resolution)
+	}
+
+	// Open session control and defer its release. A prompter is required to
+	// pause and resume sessions around conflict resolution, but there's no
+	// progress display to which its messages could be relayed.
+	control, release, err := l.openSessionControl(ctx, projectName, newStatusUpdater(ctx, "Mutagen"))
+	if err != nil {
+		return err
+	}
+	defer release()
+	synchronizationService, prompter, sidecarID := control.synchronizationService, control.prompter, control.sidecarID
+
+	// Query the sessions.
+	sessionSelection, err := control.resolveSelection(ctx, SessionKindSynchronization, names)
+	if err != nil {
+		return err
+	}
+	response, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: sessionSelection})
+	if err != nil {
+		return fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = response.EnsureValid(); err != nil {
+		return fmt.Errorf("invalid synchronization session listing response received: %w", err)
+	}
+
+	// Process each session's conflicts.
+	out := l.dockerCLI.Out()
+	input := bufio.NewReader(l.dockerCLI.In())
+	var conflicted, resolved []string
+	for _, state := range response.SessionStates {
+		// Skip sessions without conflicts.
+		if len(state.Conflicts) == 0 && state.ExcludedConflicts == 0 {
+			continue
+		}
+		conflicted = append(conflicted, state.Session.Name)
+
+		// Print the session header.
+		fmt.Fprintf(out, "%s (%d conflicts):\n",
+			state.Session.Name, uint64(len(state.Conflicts))+state.ExcludedConflicts,
+		)
+
+		// Print and (optionally) resolve each conflict. The session is paused
+		// (if it isn't already) before removing any content so that it doesn't
+		// synchronize concurrently with removal.
+		sessionSelection := &selection.Selection{Specifications: []string{state.Session.Identifier}}
+		var sessionResolved, sessionPaused bool
+		for c, conflict := range state.Conflicts {
+			printConflict(out, c+1, conflict)
+			if resolution == ConflictResolutionNone {
+				continue
+			}
+			winner := resolution
+			if resolution == ConflictResolutionPrompt {
+				if winner, err = promptConflictWinner(input, out); err != nil {
+					if sessionPaused {
+						synchronizationResumeWithSelection(ctx, synchronizationService, prompter, sessionSelection)
+					}
+					return err
+				} else if winner == "" {
+					continue
+				}
+			}
+			if !sessionPaused && !state.Session.Paused {
+				if err := synchronizationPauseWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+					return fmt.Errorf("unable to pause synchronization session (%s): %w", state.Session.Name, err)
+				}
+				sessionPaused = true
+			}
+			if err := l.resolveConflict(ctx, state.Session, sidecarID, conflict, winner); err != nil {
+				fmt.Fprintf(out, "\tUnable to resolve conflict: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "\tResolved in favor of %s\n", winner)
+			sessionResolved = true
+		}
+		if sessionPaused {
+			if err := synchronizationResumeWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+				return fmt.Errorf("unable to resume synchronization session (%s): %w", state.Session.Name, err)
+			}
+		}
+		if state.ExcludedConflicts > 0 {
+			fmt.Fprintf(out, "\t...+%d more (rerun once the above conflicts are resolved)...\n", state.ExcludedConflicts)
+		}
+		if sessionResolved {
+			resolved = append(resolved, state.Session.Name)
+		}
+	}
+
+	// Handle the case of no conflicts.
+	if len(conflicted) == 0 {
+		fmt.Fprintln(out, "No conflicts")
+		return nil
+	}
+
+	// Flush any sessions with resolved conflicts so that resolutions propagate.
+	if len(resolved) > 0 {
+		return l.FlushSynchronizationSessions(ctx, projectName, resolved)
+	}
+
+	// Success.
+	return nil
+}
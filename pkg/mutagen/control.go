@@ -0,0 +1,658 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/compose-spec/compose-go/types"
+
+	"github.com/docker/compose/v2/pkg/progress"
+
+	"github.com/mutagen-io/mutagen/cmd/mutagen/forward"
+	"github.com/mutagen-io/mutagen/cmd/mutagen/sync"
+
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+// errNoSidecar indicates that a project doesn't have a Mutagen Compose sidecar
+// container, and thus doesn't have any Mutagen sessions.
+var errNoSidecar = errors.New("project has no Mutagen Compose sidecar container")
+
+// projectSidecarID returns the identifier of the Mutagen Compose sidecar
+// container for the specified project, or errNoSidecar if it doesn't exist.
+func (l *Liaison) projectSidecarID(ctx context.Context, projectName string) (string, error) {
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return "", err
+	} else if sidecar == nil {
+		return "", errNoSidecar
+	}
+	return sidecar.ID, nil
+}
+
+// sessionControl provides access to the Mutagen sessions belonging to a
+// project's Mutagen Compose sidecar container.
+type sessionControl struct {
+	// sidecarID is the identifier of the sidecar container.
+	sidecarID string
+	// daemonConnection is the shared Mutagen daemon connection.
+	daemonConnection *grpc.ClientConn
+	// forwardingService is the forwarding service client.
+	forwardingService forwardingsvc.ForwardingClient
+	// synchronizationService is the synchronization service client.
+	synchronizationService synchronizationsvc.SynchronizationClient
+	// prompter is the identifier of the prompter relaying to the status
+	// updater, if any.
+	prompter string
+}
+
+// openSessionControl identifies the Mutagen Compose sidecar container for the
+// specified project and connects to the Mutagen daemon. If a status updater is
+// specified, then prompting is relayed to it until the returned function is
+// invoked (which must be done once the session control is no longer needed).
+func (l *Liaison) openSessionControl(ctx context.Context, projectName string, status *statusUpdater) (*sessionControl, func(), error) {
+	// Identify the sidecar container.
+	sidecarID, err := l.projectSidecarID(ctx, projectName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(status)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Relay prompting to the status updater, if any.
+	var prompter string
+	releasePrompter := func() {}
+	if status != nil {
+		prompter, releasePrompter, err = l.sharedPrompter(status)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
+		}
+	}
+
+	// Done.
+	return &sessionControl{
+		sidecarID:              sidecarID,
+		daemonConnection:       daemonConnection,
+		forwardingService:      forwardingsvc.NewForwardingClient(daemonConnection),
+		synchronizationService: synchronizationsvc.NewSynchronizationClient(daemonConnection),
+		prompter:               prompter,
+	}, releasePrompter, nil
+}
+
+// sessionIdentifiers returns the identifiers of the sidecar container's
+// sessions of the specified kind, keyed by session name, after verifying that
+// the sessions actually belong to the sidecar container. Temporary sessions
+// are excluded.
+func (c *sessionControl) sessionIdentifiers(ctx context.Context, kind string) (map[string][]string, error) {
+	sidecarSelection := sidecarDefinedSessionSelection(c.sidecarID)
+	nameToIdentifiers := make(map[string][]string)
+	if kind == SessionKindForwarding {
+		response, err := c.forwardingService.List(ctx, &forwardingsvc.ListRequest{Selection: sidecarSelection})
+		if err != nil {
+			return nil, fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		} else if err = response.EnsureValid(); err != nil {
+			return nil, fmt.Errorf("invalid forwarding session listing response received: %w", err)
+		} else if err = checkSidecarSessionOwnership(response.SessionStates, nil, c.sidecarID); err != nil {
+			return nil, err
+		}
+		for _, state := range response.SessionStates {
+			nameToIdentifiers[state.Session.Name] = append(nameToIdentifiers[state.Session.Name], state.Session.Identifier)
+		}
+	} else {
+		response, err := c.synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: sidecarSelection})
+		if err != nil {
+			return nil, fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		} else if err = response.EnsureValid(); err != nil {
+			return nil, fmt.Errorf("invalid synchronization session listing response received: %w", err)
+		} else if err = checkSidecarSessionOwnership(nil, response.SessionStates, c.sidecarID); err != nil {
+			return nil, err
+		}
+		for _, state := range response.SessionStates {
+			nameToIdentifiers[state.Session.Name] = append(nameToIdentifiers[state.Session.Name], state.Session.Identifier)
+		}
+	}
+	return nameToIdentifiers, nil
+}
+
+// resolveSelection computes the selection criteria for the named sessions of
+// the specified kind belonging to the sidecar container. If no names are
+// specified, then all of the sidecar container's sessions are selected. Names
+// are only resolved within the sidecar container's sessions, so sessions from
+// other projects with the same name will never be selected. Temporary sessions
+// (e.g. those used for seeding or pulling by another invocation) are never
+// selected.
+func (c *sessionControl) resolveSelection(ctx context.Context, kind string, names []string) (*selection.Selection, error) {
+	// Query the sidecar container's sessions.
+	nameToIdentifiers, err := c.sessionIdentifiers(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	// If no names have been specified, then select all sessions.
+	if len(names) == 0 {
+		return sidecarDefinedSessionSelection(c.sidecarID), nil
+	}
+
+	// Resolve names to session identifiers.
+	identifiers := make([]string, 0, len(names))
+	for _, name := range names {
+		matches, ok := nameToIdentifiers[name]
+		if !ok {
+			return nil, fmt.Errorf("no %s session named \"%s\" exists for project", kind, name)
+		}
+		identifiers = append(identifiers, matches...)
+	}
+	return &selection.Selection{Specifications: identifiers}, nil
+}
+
+// controlSessions performs an operation on the named sessions of the specified
+// kind for the specified project (or all of its sessions of that kind if no
+// names are specified). The title is used as both the progress title and the
+// working status (e.g. "Flushing"), and the done description is used once the
+// operation completes.
+func (l *Liaison) controlSessions(
+	ctx context.Context,
+	projectName, kind string,
+	names []string,
+	title, done string,
+	operation func(context.Context, *sessionControl, *selection.Selection) error,
+) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		// Apply the operation timeout and defer cancellation of the operation
+		// context.
+		ctx, cancel, err := l.operationContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		// Create a Mutagen status updater, start the Mutagen status update,
+		// and defer its finalization.
+		status := newStatusUpdater(ctx, "Mutagen")
+		status.working(fmt.Sprintf("%s %s sessions", title, kind))
+		var statusErr error
+		defer func() {
+			if statusErr != nil {
+				status.error(statusErr)
+			} else {
+				status.done(done)
+			}
+		}()
+
+		// Lock the project (to serialize operations with other Mutagen Compose
+		// invocations) and defer release of the lock.
+		ctx, unlock, err := l.lockProject(ctx, status, projectName)
+		if err != nil {
+			statusErr = fmt.Errorf("unable to lock project: %w", err)
+			return statusErr
+		}
+		defer unlock()
+
+		// Open session control and defer its release.
+		control, release, err := l.openSessionControl(ctx, projectName, status)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		defer release()
+
+		// Resolve the session selection.
+		sessionSelection, err := control.resolveSelection(ctx, kind, names)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		status.interruptionDetails = l.describeSessionStates(sessionSelection)
+
+		// Report the states of the selected sessions individually while
+		// performing the operation. We track by name (rather than by
+		// identifier) so that terminated sessions are reported as such.
+		var trackedNames map[string]bool
+		if len(names) > 0 {
+			trackedNames = make(map[string]bool, len(names))
+			for _, name := range names {
+				trackedNames[name] = true
+			}
+		}
+		forwardingService, synchronizationService := control.forwardingService, control.synchronizationService
+		if kind == SessionKindForwarding {
+			synchronizationService = nil
+		} else {
+			forwardingService = nil
+		}
+		sessionProgress := trackSessionProgress(ctx, forwardingService, synchronizationService, sidecarDefinedSessionSelection(control.sidecarID), trackedNames)
+		defer sessionProgress.stop()
+
+		// Perform the operation.
+		if err := operation(ctx, control, sessionSelection); err != nil {
+			statusErr = fmt.Errorf("%s %s failed: %w", kind, strings.ToLower(title), err)
+			return statusErr
+		}
+
+		// Success.
+		return nil
+	}, l.dockerCLI.Err(), title)
+}
+
+// controlSynchronizationSessions performs an operation on the named
+// synchronization sessions for the specified project (or all of its
+// synchronization sessions if no names are specified). It behaves like
+// controlSessions.
+func (l *Liaison) controlSynchronizationSessions(
+	ctx context.Context,
+	projectName string,
+	names []string,
+	title, done string,
+	operation func(context.Context, synchronizationsvc.SynchronizationClient, string, *selection.Selection) error,
+) error {
+	return l.controlSessions(ctx, projectName, SessionKindSynchronization, names, title, done,
+		func(ctx context.Context, control *sessionControl, sessionSelection *selection.Selection) error {
+			return operation(ctx, control.synchronizationService, control.prompter, sessionSelection)
+		},
+	)
+}
+
+// FlushSynchronizationSessions flushes the named synchronization sessions for
+// the specified project (or all of its synchronization sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) FlushSynchronizationSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlSynchronizationSessions(ctx, projectName, names, "Flushing", "Flushed", synchronizationFlushWithSelection)
+}
+
+// PauseSynchronizationSessions pauses the named synchronization sessions for
+// the specified project (or all of its synchronization sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) PauseSynchronizationSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlSynchronizationSessions(ctx, projectName, names, "Pausing", "Paused", synchronizationPauseWithSelection)
+}
+
+// ResumeSynchronizationSessions resumes the named synchronization sessions for
+// the specified project (or all of its synchronization sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) ResumeSynchronizationSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlSynchronizationSessions(ctx, projectName, names, "Resuming", "Resumed", synchronizationResumeWithSelection)
+}
+
+// ResetSynchronizationSessions resets the named synchronization sessions for
+// the specified project (or all of its synchronization sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) ResetSynchronizationSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlSynchronizationSessions(ctx, projectName, names, "Resetting", "Reset", synchronizationResetWithSelection)
+}
+
+// TerminateSynchronizationSessions terminates the named synchronization
+// sessions for the specified project (or all of its synchronization sessions if
+// no names are specified). Terminated sessions will be recreated by the next
+// up operation. This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) TerminateSynchronizationSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlSynchronizationSessions(ctx, projectName, names, "Terminating", "Terminated", synchronizationTerminateWithSelection)
+}
+
+// ListSynchronizationSessions prints the named synchronization sessions for the
+// specified project (or all of its synchronization sessions if no names are
+// specified) using Mutagen's standard listing format. This method must only be
+// called after the Docker CLI has been registered.
+func (l *Liaison) ListSynchronizationSessions(ctx context.Context, projectName string, names []string, long bool) error {
+	// Open session control and defer its release.
+	control, release, err := l.openSessionControl(ctx, projectName, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Resolve the session selection.
+	sessionSelection, err := control.resolveSelection(ctx, SessionKindSynchronization, names)
+	if err != nil {
+		return err
+	}
+
+	// Perform the listing.
+	if err := sync.ListWithSelection(control.daemonConnection, sessionSelection, long); err != nil {
+		return fmt.Errorf("synchronization listing failed: %w", err)
+	}
+
+	// Success.
+	return nil
+}
+
+// normalizePullPath validates and normalizes a path (relative to a
+// synchronization root) specified for a pull operation, returning it in
+// slash-separated form (or empty for the synchronization root).
+func normalizePullPath(target string) (string, error) {
+	target = path.Clean(filepath.ToSlash(target))
+	if path.IsAbs(target) || filepath.IsAbs(target) {
+		return "", fmt.Errorf("path (%s) must be relative to the synchronization root", target)
+	} else if target == ".." || strings.HasPrefix(target, "../") {
+		return "", fmt.Errorf("path (%s) must not escape the synchronization root", target)
+	} else if target == "." {
+		return "", nil
+	}
+	return target, nil
+}
+
+// PullSynchronizationSession copies content from the volume endpoint of the
+// named synchronization session back to its local endpoint. It is designed for
+// use with oneshot sessions. If paths (relative to the synchronization root)
+// are specified, then only those paths are copied, otherwise the entire
+// synchronization root is copied. By default, copying uses one-way-safe
+// semantics, so local files that don't exist in the volume (or that conflict
+// with volume content) are left in place. If replace is true, then copying
+// uses one-way-replica semantics, so the targeted local content is replaced
+// with an exact copy of the volume content, deleting local files that don't
+// exist in the volume. This method must only be called after the Docker CLI
+// and Docker flags have been registered.
+func (l *Liaison) PullSynchronizationSession(ctx context.Context, project *types.Project, name string, paths []string, replace bool) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		// Apply the operation timeout and defer cancellation of the operation
+		// context.
+		ctx, cancel, err := l.operationContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		// Create a Mutagen status updater, start the Mutagen status update,
+		// and defer its finalization.
+		status := newStatusUpdater(ctx, "Mutagen")
+		status.working(fmt.Sprintf("Pulling synchronization session \"%s\"", name))
+		var statusErr error
+		defer func() {
+			if statusErr != nil {
+				status.error(statusErr)
+			} else {
+				status.done("Pulled")
+			}
+		}()
+
+		// Process Mutagen extensions for the project.
+		if err := l.processProject(project); err != nil {
+			statusErr = fmt.Errorf("unable to process project: %w", err)
+			return statusErr
+		}
+
+		// Look up the session definition.
+		specification, ok := l.synchronization[name]
+		if !ok {
+			statusErr = fmt.Errorf("no synchronization session named \"%s\" is defined for project", name)
+			return statusErr
+		} else if !l.synchronizationOneshot[name] {
+			statusErr = fmt.Errorf("synchronization session \"%s\" doesn't have a oneshot lifecycle", name)
+			return statusErr
+		}
+
+		// Validate and normalize paths.
+		subpaths := make([]string, 0, len(paths))
+		for _, p := range paths {
+			if subpath, err := normalizePullPath(p); err != nil {
+				statusErr = err
+				return statusErr
+			} else {
+				subpaths = append(subpaths, subpath)
+			}
+		}
+		if len(subpaths) == 0 {
+			subpaths = append(subpaths, "")
+		}
+
+		// Lock the project (to serialize operations with other Mutagen Compose
+		// invocations) and defer release of the lock.
+		ctx, unlock, err := l.lockProject(ctx, status, project.Name)
+		if err != nil {
+			statusErr = fmt.Errorf("unable to lock project: %w", err)
+			return statusErr
+		}
+		defer unlock()
+
+		// Open session control, defer its release, and prepare session
+		// specifications to target the sidecar container.
+		control, release, err := l.openSessionControl(ctx, project.Name, status)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		defer release()
+		l.prepareSpecifications(control.sidecarID)
+		status.interruptionDetails = l.describeSessionStates(sidecarSessionSelection(control.sidecarID))
+
+		// Pull each path.
+		for _, subpath := range subpaths {
+			if subpath != "" {
+				status.working(fmt.Sprintf("Pulling %s", subpath))
+			}
+			if err := synchronizationRunOnceWithSpecification(ctx, status, control.synchronizationService, control.prompter,
+				synchronizationPullSpecification(specification, control.sidecarID, subpath, replace),
+			); err != nil {
+				if subpath == "" {
+					statusErr = fmt.Errorf("unable to pull synchronization root: %w", err)
+				} else {
+					statusErr = fmt.Errorf("unable to pull %s: %w", subpath, err)
+				}
+				return statusErr
+			}
+		}
+
+		// Success.
+		return nil
+	}, l.dockerCLI.Err(), "Pulling")
+}
+
+// controlForwardingSessions performs an operation on the named forwarding
+// sessions for the specified project (or all of its forwarding sessions if no
+// names are specified). It behaves like controlSessions.
+func (l *Liaison) controlForwardingSessions(
+	ctx context.Context,
+	projectName string,
+	names []string,
+	title, done string,
+	operation func(context.Context, forwardingsvc.ForwardingClient, string, *selection.Selection) error,
+) error {
+	return l.controlSessions(ctx, projectName, SessionKindForwarding, names, title, done,
+		func(ctx context.Context, control *sessionControl, sessionSelection *selection.Selection) error {
+			return operation(ctx, control.forwardingService, control.prompter, sessionSelection)
+		},
+	)
+}
+
+// PauseForwardingSessions pauses the named forwarding sessions for the
+// specified project (or all of its forwarding sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) PauseForwardingSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlForwardingSessions(ctx, projectName, names, "Pausing", "Paused", forwardingPauseWithSelection)
+}
+
+// ResumeForwardingSessions resumes the named forwarding sessions for the
+// specified project (or all of its forwarding sessions if no names are
+// specified). This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) ResumeForwardingSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlForwardingSessions(ctx, projectName, names, "Resuming", "Resumed", forwardingResumeWithSelection)
+}
+
+// TerminateForwardingSessions terminates the named forwarding sessions for the
+// specified project (or all of its forwarding sessions if no names are
+// specified). Terminated sessions will be recreated by the next up operation.
+// This method must only be called after the Docker CLI has been registered.
+func (l *Liaison) TerminateForwardingSessions(ctx context.Context, projectName string, names []string) error {
+	return l.controlForwardingSessions(ctx, projectName, names, "Terminating", "Terminated", forwardingTerminateWithSelection)
+}
+
+// ListForwardingSessions prints the named forwarding sessions for the specified
+// project (or all of its forwarding sessions if no names are specified) using
+// Mutagen's standard listing format. This method must only be called after the
+// Docker CLI has been registered.
+func (l *Liaison) ListForwardingSessions(ctx context.Context, projectName string, names []string, long bool) error {
+	// Open session control and defer its release.
+	control, release, err := l.openSessionControl(ctx, projectName, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Resolve the session selection.
+	sessionSelection, err := control.resolveSelection(ctx, SessionKindForwarding, names)
+	if err != nil {
+		return err
+	}
+
+	// Perform the listing.
+	if err := forward.ListWithSelection(control.daemonConnection, sessionSelection, long); err != nil {
+		return fmt.Errorf("forwarding listing failed: %w", err)
+	}
+
+	// Success.
+	return nil
+}
+
+// RecreateForwardingSessions recreates the named forwarding sessions for the
+// specified project (or all of its forwarding sessions if no names are
+// specified) from the project's current definitions. Unlike other operations,
+// the named sessions need only be defined by the project and don't need to
+// currently exist. Existing sessions are only terminated once their
+// replacements have been created. Since replacements may need to bind the same
+// listeners, existing sessions are paused during creation and are resumed if
+// creation fails. This method must only be called after the Docker CLI and
+// Docker flags have been registered.
+func (l *Liaison) RecreateForwardingSessions(ctx context.Context, project *types.Project, names []string) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		// Apply the operation timeout and defer cancellation of the operation
+		// context.
+		ctx, cancel, err := l.operationContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		// Create a Mutagen status updater, start the Mutagen status update,
+		// and defer its finalization.
+		status := newStatusUpdater(ctx, "Mutagen")
+		status.working("Recreating forwarding sessions")
+		var statusErr error
+		defer func() {
+			if statusErr != nil {
+				status.error(statusErr)
+			} else {
+				status.done("Recreated")
+			}
+		}()
+
+		// Process Mutagen extensions for the project.
+		if err := l.processProject(project); err != nil {
+			statusErr = fmt.Errorf("unable to process project: %w", err)
+			return statusErr
+		}
+
+		// Determine which sessions to recreate.
+		if len(names) == 0 {
+			for name := range l.forwarding {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		for _, name := range names {
+			if _, ok := l.forwarding[name]; !ok {
+				statusErr = fmt.Errorf("no forwarding session named \"%s\" is defined for project", name)
+				return statusErr
+			}
+		}
+
+		// Lock the project (to serialize operations with other Mutagen Compose
+		// invocations) and defer release of the lock.
+		ctx, unlock, err := l.lockProject(ctx, status, project.Name)
+		if err != nil {
+			statusErr = fmt.Errorf("unable to lock project: %w", err)
+			return statusErr
+		}
+		defer unlock()
+
+		// Open session control, defer its release, and prepare session
+		// specifications to target the sidecar container.
+		control, release, err := l.openSessionControl(ctx, project.Name, status)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		defer release()
+		l.prepareSpecifications(control.sidecarID)
+		status.interruptionDetails = l.describeSessionStates(sidecarSessionSelection(control.sidecarID))
+
+		// Identify existing sessions with the specified names.
+		nameToIdentifiers, err := control.sessionIdentifiers(ctx, SessionKindForwarding)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		var existing []string
+		for _, name := range names {
+			existing = append(existing, nameToIdentifiers[name]...)
+		}
+		existingSelection := &selection.Selection{Specifications: existing}
+
+		// Pause existing sessions so that their listeners are released.
+		if len(existing) > 0 {
+			status.working("Pausing existing forwarding sessions")
+			if err := forwardingPauseWithSelection(ctx, control.forwardingService, control.prompter, existingSelection); err != nil {
+				statusErr = fmt.Errorf("forwarding pause failed: %w", err)
+				return statusErr
+			}
+		}
+
+		// Create the sessions. If creation fails, then terminate any sessions
+		// that were created and resume the existing sessions. This cleanup isn't
+		// subject to cancellation of the operation context, since the failure
+		// may have been due to that cancellation.
+		var created []string
+		for _, name := range names {
+			status.working(fmt.Sprintf("Creating Mutagen forwarding session \"%s\"", name))
+			var session string
+			if err := retryTransient(ctx, status, "forwarding session creation", func() (err error) {
+				session, err = forwardingCreateWithSpecification(ctx, control.forwardingService, control.prompter, l.forwarding[name])
+				return err
+			}); err != nil {
+				cleanupCtx, cleanupCancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+				defer cleanupCancel()
+				if len(created) > 0 {
+					createdSelection := &selection.Selection{Specifications: created}
+					forwardingTerminateWithSelection(cleanupCtx, control.forwardingService, control.prompter, createdSelection)
+				}
+				if len(existing) > 0 {
+					forwardingResumeWithSelection(cleanupCtx, control.forwardingService, control.prompter, existingSelection)
+				}
+				statusErr = fmt.Errorf("unable to create forwarding session (%s): %w", name, err)
+				return statusErr
+			}
+			created = append(created, session)
+		}
+
+		// Terminate the existing sessions now that they've been replaced.
+		if len(existing) > 0 {
+			status.working("Terminating replaced forwarding sessions")
+			if err := forwardingTerminateWithSelection(ctx, control.forwardingService, control.prompter, existingSelection); err != nil {
+				statusErr = fmt.Errorf("forwarding termination failed: %w", err)
+				return statusErr
+			}
+		}
+
+		// Success.
+		return nil
+	}, l.dockerCLI.Err(), "Recreating")
+}
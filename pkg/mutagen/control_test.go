@@ -0,0 +1,43 @@
+package mutagen
+
+import (
+	"testing"
+)
+
+// TestNormalizePullPath tests normalizePullPath.
+func TestNormalizePullPath(t *testing.T) {
+	testCases := []struct {
+		target      string
+		expected    string
+		expectError bool
+	}{
+		{".", "", false},
+		{"", "", false},
+		{"./", "", false},
+		{"src", "src", false},
+		{"src/", "src", false},
+		{"./src/main.go", "src/main.go", false},
+		{"src/../docs", "docs", false},
+		{"src/./lib//util", "src/lib/util", false},
+		{"..", "", true},
+		{"../sibling", "", true},
+		{"src/../../sibling", "", true},
+		{"/etc/passwd", "", true},
+		{"..data", "..data", false},
+	}
+	for _, testCase := range testCases {
+		normalized, err := normalizePullPath(testCase.target)
+		if testCase.expectError {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", testCase.target, normalized)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: unexpected error: %v", testCase.target, err)
+			continue
+		}
+		if normalized != testCase.expected {
+			t.Errorf("%q: result mismatch: %q != %q", testCase.target, normalized, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/mutagen-io/mutagen/cmd/mutagen/daemon"
+
+	daemonpkg "github.com/mutagen-io/mutagen/pkg/daemon"
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	mutageninfo "github.com/mutagen-io/mutagen/pkg/mutagen"
+	"github.com/mutagen-io/mutagen/pkg/prompting"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	daemonsvc "github.com/mutagen-io/mutagen/pkg/service/daemon"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	promptingsvc "github.com/mutagen-io/mutagen/pkg/service/prompting"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+const (
+	// DataDirectoryEnvironmentVariable is the environment variable that can be
+	// used to specify a project-scoped Mutagen data directory (and thus a
+	// project-scoped Mutagen daemon). It takes precedence over any data
+	// directory specified in the x-mutagen section of the project.
+	DataDirectoryEnvironmentVariable = "MUTAGEN_COMPOSE_DATA_DIRECTORY"
+	// mutagenDataDirectoryEnvironmentVariable is the environment variable used
+	// by Mutagen to override its data directory. Mutagen only reads its data
+	// directory from the environment, and autostarted Mutagen daemons inherit
+	// it from the process that starts them.
+	mutagenDataDirectoryEnvironmentVariable = "MUTAGEN_DATA_DIRECTORY"
+	// sidecarDataDirectoryLabelKey is the name of the label applied to the
+	// Mutagen Compose sidecar container to record the project-scoped Mutagen
+	// data directory (if any) used for its sessions. This allows operations
+	// that don't load the project definition to target the correct daemon.
+	sidecarDataDirectoryLabelKey = "io.mutagen.compose.data-directory"
+	// DaemonAutoRestartEnvironmentVariable is the environment variable that
+	// can be set to "1" to automatically restart a running Mutagen daemon whose
+	// version is incompatible with the Mutagen version embedded in Mutagen
+	// Compose.
+	DaemonAutoRestartEnvironmentVariable = "MUTAGEN_COMPOSE_DAEMON_AUTO_RESTART"
+	// daemonVersionQueryTimeout is the maximum amount of time to wait for the
+	// Mutagen daemon to report its version.
+	daemonVersionQueryTimeout = 5 * time.Second
+	// daemonStopWaitInterval is the wait period between checks for Mutagen
+	// daemon termination when restarting the daemon.
+	daemonStopWaitInterval = 100 * time.Millisecond
+	// daemonStopTimeout is the maximum amount of time to wait for the Mutagen
+	// daemon to terminate when restarting the daemon.
+	daemonStopTimeout = 10 * time.Second
+)
+
+// dataDirectory returns the project-scoped Mutagen data directory that should
+// be used, or an empty string if the default Mutagen data directory (and
+// daemon) should be used.
+func (l *Liaison) dataDirectory() (string, error) {
+	if path := os.Getenv(DataDirectoryEnvironmentVariable); path != "" {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve %s path: %w", DataDirectoryEnvironmentVariable, err)
+		}
+		return absolute, nil
+	}
+	return l.projectDataDirectory, nil
+}
+
+// recordSidecarLabels records the project name and the project-scoped Mutagen
+// data directory (if any) recorded in a Mutagen Compose sidecar container's
+// labels, ensuring that subsequent daemon connections target the daemon that
+// manages the container's sessions and that subsequent session operations lock
+// the correct project. The data directory is only recorded if the sidecar
+// container was labeled with one, since sidecar containers created by older
+// versions of Mutagen Compose lack the label and the data directory loaded
+// from the project (if any) should be used for them.
+func (l *Liaison) recordSidecarLabels(labels map[string]string) {
+	l.sidecarProjectName = labels[api.ProjectLabel]
+	if dataDirectory, ok := labels[sidecarDataDirectoryLabelKey]; ok {
+		l.projectDataDirectory = dataDirectory
+	}
+}
+
+// formatDaemonVersion formats a Mutagen version for display.
+func formatDaemonVersion(major, minor, patch uint64, tag string) string {
+	if tag != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", major, minor, patch, tag)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+}
+
+// checkDaemonVersion queries the version of the Mutagen daemon and verifies
+// that it's compatible with the Mutagen version embedded in Mutagen Compose.
+// Mutagen requires an exact version match between clients and the daemon. It
+// returns the daemon version and whether or not it's compatible.
+func checkDaemonVersion(daemonConnection *grpc.ClientConn) (string, bool, error) {
+	// Query the daemon version.
+	ctx, cancel := context.WithTimeout(context.Background(), daemonVersionQueryTimeout)
+	defer cancel()
+	version, err := daemonsvc.NewDaemonClient(daemonConnection).Version(ctx, &daemonsvc.VersionRequest{})
+	if err != nil {
+		return "", false, fmt.Errorf("unable to query Mutagen daemon version: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	}
+
+	// Check compatibility.
+	compatible := version.Major == mutageninfo.VersionMajor &&
+		version.Minor == mutageninfo.VersionMinor &&
+		version.Patch == mutageninfo.VersionPatch &&
+		version.Tag == mutageninfo.VersionTag
+	return formatDaemonVersion(version.Major, version.Minor, version.Patch, version.Tag), compatible, nil
+}
+
+// overrideDataDirectory overrides the Mutagen data directory used by the
+// current process (and any Mutagen daemon that it starts) and returns a
+// function that restores the previous data directory. Mutagen doesn't provide
+// a mechanism for specifying the data directory explicitly, so the override is
+// applied via the environment and should be restricted to the duration of the
+// operations that require it. Callers must serialize overrides, which is
+// handled by the daemon client lock.
+func overrideDataDirectory(path string) (func(), error) {
+	previous, overridden := os.LookupEnv(mutagenDataDirectoryEnvironmentVariable)
+	if err := os.Setenv(mutagenDataDirectoryEnvironmentVariable, path); err != nil {
+		return nil, fmt.Errorf("unable to set Mutagen data directory: %w", err)
+	}
+	return func() {
+		if overridden {
+			os.Setenv(mutagenDataDirectoryEnvironmentVariable, previous)
+		} else {
+			os.Unsetenv(mutagenDataDirectoryEnvironmentVariable)
+		}
+	}, nil
+}
+
+// restartDaemon stops the Mutagen daemon to which the specified connection is
+// established and waits for it to terminate. The connection is closed. The
+// daemon will be restarted by the next autostarting connection.
+func restartDaemon(daemonConnection *grpc.ClientConn) error {
+	// Terminate the daemon and close the connection. We don't check the
+	// response or error, because the daemon may terminate before it has a
+	// chance to send the response.
+	ctx, cancel := context.WithTimeout(context.Background(), daemonStopTimeout)
+	daemonsvc.NewDaemonClient(daemonConnection).Terminate(ctx, &daemonsvc.TerminateRequest{})
+	cancel()
+	daemonConnection.Close()
+
+	// Wait for the daemon to release its lock, which indicates that it has
+	// terminated and that a new daemon can be started.
+	deadline := time.Now().Add(daemonStopTimeout)
+	for {
+		if lock, err := daemonpkg.AcquireLock(); err == nil {
+			if err := lock.Release(); err != nil {
+				return fmt.Errorf("unable to release Mutagen daemon lock: %w", err)
+			}
+			return nil
+		} else if time.Now().After(deadline) {
+			return errors.New("timed out waiting for Mutagen daemon to terminate")
+		}
+		time.Sleep(daemonStopWaitInterval)
+	}
+}
+
+// connectToDaemon connects to the Mutagen daemon, starting it if necessary. If
+// a project-scoped data directory is specified, then the connection (and any
+// autostart or restart) targets the daemon for that data directory. The daemon
+// version is checked for compatibility with the Mutagen version embedded in
+// Mutagen Compose. If the daemon is incompatible, then it is either restarted
+// (if automatic restarts have been enabled) or an error describing the
+// mismatch is returned. Restarts are reported via the specified status
+// updater, if any, or to standard error if the operation doesn't display
+// status.
+func (l *Liaison) connectToDaemon(dataDirectory string, status *statusUpdater) (*grpc.ClientConn, error) {
+	// Target the project-scoped data directory, if specified.
+	if dataDirectory != "" {
+		restore, err := overrideDataDirectory(dataDirectory)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+
+	// Connect to the daemon. We perform the version check ourselves so that we
+	// can provide a more actionable error in the event of a mismatch.
+	daemonConnection, err := daemon.Connect(true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the daemon version.
+	daemonVersion, compatible, err := checkDaemonVersion(daemonConnection)
+	if err != nil {
+		daemonConnection.Close()
+		return nil, err
+	} else if compatible {
+		return daemonConnection, nil
+	}
+
+	// If automatic restarts haven't been enabled, then report the mismatch.
+	// The stop command has to target the same data directory as the daemon,
+	// otherwise it will stop the default daemon.
+	if os.Getenv(DaemonAutoRestartEnvironmentVariable) != "1" {
+		daemonConnection.Close()
+		stopCommand := "mutagen daemon stop"
+		if dataDirectory != "" {
+			stopCommand = fmt.Sprintf("%s=%s %s", mutagenDataDirectoryEnvironmentVariable, dataDirectory, stopCommand)
+		}
+		return nil, fmt.Errorf(
+			"Mutagen daemon version (%s) is incompatible with the Mutagen version required by Mutagen Compose (%s); "+
+				"stop the daemon using \"%s\" or set %s=1 to restart it automatically",
+			daemonVersion, mutageninfo.Version, stopCommand, DaemonAutoRestartEnvironmentVariable,
+		)
+	}
+
+	// Restart the daemon and reconnect, this time enforcing a version match.
+	notice := fmt.Sprintf("Restarting incompatible Mutagen daemon (version %s, required %s)",
+		daemonVersion, mutageninfo.Version,
+	)
+	if status != nil {
+		status.working(notice)
+	} else if l.dockerCLI != nil {
+		fmt.Fprintln(l.dockerCLI.Err(), notice)
+	}
+	if err := restartDaemon(daemonConnection); err != nil {
+		return nil, fmt.Errorf("unable to restart incompatible Mutagen daemon (version %s): %w", daemonVersion, err)
+	}
+	return daemon.Connect(true, true)
+}
+
+// promptingRelay implements prompting.Prompter by relaying messages and
+// prompts to the most recently registered target. It allows a single prompter
+// hosted on the shared daemon connection to be used by successive (or nested)
+// operations, each of which reports to its own status updater.
+type promptingRelay struct {
+	// lock serializes access to targets.
+	lock sync.Mutex
+	// targets is the stack of registered targets.
+	targets []prompting.Prompter
+}
+
+// register registers a target and returns a function that unregisters it.
+func (r *promptingRelay) register(target prompting.Prompter) func() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.targets = append(r.targets, target)
+	return func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		for t := len(r.targets) - 1; t >= 0; t-- {
+			if r.targets[t] == target {
+				r.targets = append(r.targets[:t], r.targets[t+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// target returns the current target, if any.
+func (r *promptingRelay) target() prompting.Prompter {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.targets) == 0 {
+		return nil
+	}
+	return r.targets[len(r.targets)-1]
+}
+
+// Message implements prompting.Prompter.Message. Messages received without a
+// registered target are discarded.
+func (r *promptingRelay) Message(message string) error {
+	if target := r.target(); target != nil {
+		return target.Message(message)
+	}
+	return nil
+}
+
+// Prompt implements prompting.Prompter.Prompt.
+func (r *promptingRelay) Prompt(prompt string) (string, error) {
+	if target := r.target(); target != nil {
+		return target.Prompt(prompt)
+	}
+	return "", errors.New("prompting not supported")
+}
+
+// daemonClient holds the Mutagen daemon connection and prompter shared by
+// operations performed during a single invocation.
+type daemonClient struct {
+	// lock serializes access to the remaining fields.
+	lock sync.Mutex
+	// connection is the daemon connection, if established.
+	connection *grpc.ClientConn
+	// dataDirectory is the project-scoped data directory for which the
+	// connection was established.
+	dataDirectory string
+	// prompter is the identifier of the prompter hosted on the connection, if
+	// any.
+	prompter string
+	// promptingCancel cancels prompter hosting.
+	promptingCancel context.CancelFunc
+	// promptingErrors reports prompter hosting failures.
+	promptingErrors <-chan error
+	// relay relays prompter messages to the current operation.
+	relay promptingRelay
+}
+
+// closeLocked terminates prompter hosting and closes the daemon connection (if
+// established). The caller must hold the client lock.
+func (c *daemonClient) closeLocked() error {
+	if c.promptingCancel != nil {
+		c.promptingCancel()
+		<-c.promptingErrors
+		c.prompter, c.promptingCancel, c.promptingErrors = "", nil, nil
+	}
+	if c.connection != nil {
+		err := c.connection.Close()
+		c.connection = nil
+		return err
+	}
+	return nil
+}
+
+// sharedDaemonConnection returns the Mutagen daemon connection shared by all
+// operations performed by the liaison, establishing it if necessary. Callers
+// must not close the connection. Failed connection attempts aren't cached, so
+// each caller will retry the connection (e.g. after the daemon has been stopped
+// or restarted by the user). If the project-scoped data directory changes
+// (e.g. because a sidecar container has been inspected), then the connection
+// is re-established to target the correct daemon. If a status updater is
+// specified, then it's used to report any daemon restart.
+func (l *Liaison) sharedDaemonConnection(status *statusUpdater) (*grpc.ClientConn, error) {
+	// Lock the client and defer its release.
+	l.daemon.lock.Lock()
+	defer l.daemon.lock.Unlock()
+
+	// Determine the data directory to target.
+	dataDirectory, err := l.dataDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check whether the existing connection is still usable.
+	if dataDirectory == l.daemon.dataDirectory && l.daemon.connection != nil &&
+		l.daemon.connection.GetState() != connectivity.Shutdown {
+		return l.daemon.connection, nil
+	}
+	l.daemon.closeLocked()
+
+	// Establish a new connection.
+	connection, err := l.connectToDaemon(dataDirectory, status)
+	if err != nil {
+		return nil, err
+	}
+	l.daemon.connection = connection
+	l.daemon.dataDirectory = dataDirectory
+	return connection, nil
+}
+
+// interactivePrompting determines whether or not interactive prompting is
+// supported, which requires that standard input and output be terminals. This
+// method must only be called after the Docker CLI has been registered.
+func (l *Liaison) interactivePrompting() bool {
+	return l.dockerCLI.In().IsTerminal() && l.dockerCLI.Out().IsTerminal()
+}
+
+// sharedPrompter returns the identifier of a prompter hosted on the shared
+// Mutagen daemon connection, establishing it if necessary. Messages and prompts
+// are relayed to the specified target until the returned function is invoked.
+// The prompter is message-only unless interactive prompting is supported. This
+// method must only be called after sharedDaemonConnection has returned
+// successfully.
+func (l *Liaison) sharedPrompter(target *statusUpdater) (string, func(), error) {
+	// Lock the client and defer its release.
+	l.daemon.lock.Lock()
+	defer l.daemon.lock.Unlock()
+
+	// Ensure that a connection is available.
+	if l.daemon.connection == nil {
+		return "", nil, errors.New("no daemon connection")
+	}
+
+	// If prompter hosting has failed, then discard the prompter.
+	if l.daemon.promptingCancel != nil {
+		select {
+		case <-l.daemon.promptingErrors:
+			l.daemon.promptingCancel()
+			l.daemon.prompter, l.daemon.promptingCancel, l.daemon.promptingErrors = "", nil, nil
+		default:
+		}
+	}
+
+	// Host a prompter, if necessary. Hosting is regulated by its own context
+	// since it outlives individual operations.
+	interactive := l.interactivePrompting()
+	if l.daemon.promptingCancel == nil {
+		promptingCtx, promptingCancel := context.WithCancel(context.Background())
+		prompter, promptingErrors, err := promptingsvc.Host(
+			promptingCtx, promptingsvc.NewPromptingClient(l.daemon.connection),
+			&l.daemon.relay, interactive,
+		)
+		if err != nil {
+			promptingCancel()
+			return "", nil, err
+		}
+		l.daemon.prompter = prompter
+		l.daemon.promptingCancel = promptingCancel
+		l.daemon.promptingErrors = promptingErrors
+	}
+
+	// Register the target.
+	target.interactive = interactive
+	target.terminal = l.dockerCLI.Out()
+	return l.daemon.prompter, l.daemon.relay.register(target), nil
+}
+
+// resetSharedDaemonConnection closes the shared Mutagen daemon connection (if
+// established), causing it to be re-established by the next operation. It
+// should be invoked if the daemon has been terminated.
+func (l *Liaison) resetSharedDaemonConnection() {
+	l.daemon.lock.Lock()
+	defer l.daemon.lock.Unlock()
+	l.daemon.closeLocked()
+	l.daemon.dataDirectory = ""
+}
+
+// Shutdown terminates prompter hosting and closes the shared Mutagen daemon
+// connection (if established).
+func (l *Liaison) Shutdown() error {
+	l.daemon.lock.Lock()
+	defer l.daemon.lock.Unlock()
+	return l.daemon.closeLocked()
+}
+
+// stopIdleProjectDaemon stops the project-scoped Mutagen daemon (if one is in
+// use) if it no longer has any sessions. It is a no-op when the default Mutagen
+// daemon is in use, since that daemon is shared with other projects and with
+// direct Mutagen usage. It returns true if the daemon was stopped.
+func (l *Liaison) stopIdleProjectDaemon(ctx context.Context, daemonConnection *grpc.ClientConn) (bool, error) {
+	// If there's no project-scoped data directory, then there's nothing to do.
+	if path, err := l.dataDirectory(); err != nil || path == "" {
+		return false, err
+	}
+
+	// Check for remaining forwarding sessions.
+	allSelection := &selection.Selection{All: true}
+	forwardingResponse, err := forwardingsvc.NewForwardingClient(daemonConnection).List(ctx, &forwardingsvc.ListRequest{Selection: allSelection})
+	if err != nil {
+		return false, fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = forwardingResponse.EnsureValid(); err != nil {
+		return false, fmt.Errorf("invalid forwarding session listing response received: %w", err)
+	} else if len(forwardingResponse.SessionStates) > 0 {
+		return false, nil
+	}
+
+	// Check for remaining synchronization sessions.
+	synchronizationResponse, err := synchronizationsvc.NewSynchronizationClient(daemonConnection).List(ctx, &synchronizationsvc.ListRequest{Selection: allSelection})
+	if err != nil {
+		return false, fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = synchronizationResponse.EnsureValid(); err != nil {
+		return false, fmt.Errorf("invalid synchronization session listing response received: %w", err)
+	} else if len(synchronizationResponse.SessionStates) > 0 {
+		return false, nil
+	}
+
+	// Terminate the daemon. We don't check the response or error, because the
+	// daemon may terminate before it has a chance to send the response. The
+	// shared connection is no longer usable, so reset it.
+	daemonsvc.NewDaemonClient(daemonConnection).Terminate(ctx, &daemonsvc.TerminateRequest{})
+	l.resetSharedDaemonConnection()
+
+	// Success.
+	return true, nil
+}
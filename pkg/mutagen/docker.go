@@ -30,8 +30,15 @@ func (c *dockerAPIClient) isMutagenComposeSidecar(ctx context.Context, container
 		return false, fmt.Errorf("unable to inspect container: %w", err)
 	}
 
-	// Check if this is a Mutagen Compose sidecar container.
-	return metadata.Config.Labels[sidecarRoleLabelKey] == sidecarRoleLabelValue, nil
+	// Check if this is a Mutagen Compose sidecar container. If it is, then
+	// record its project and Mutagen data directory so that any subsequent
+	// session operations lock the correct project and target the correct
+	// daemon.
+	if metadata.Config.Labels[sidecarRoleLabelKey] != sidecarRoleLabelValue {
+		return false, nil
+	}
+	c.liaison.recordSidecarLabels(metadata.Config.Labels)
+	return true, nil
 }
 
 // ContainerStart implements
@@ -123,10 +130,12 @@ func (c *dockerAPIClient) ContainerStop(ctx context.Context, container string, o
 // github.com/docker/docker/client.APIClient.ContainerRemove.
 func (c *dockerAPIClient) ContainerRemove(ctx context.Context, container string, options types.ContainerRemoveOptions) error {
 	// If this is a Mutagen compose sidecar container, then terminate associated
-	// Mutagen sessions.
+	// Mutagen sessions, unless the sidecar is being recreated, in which case
+	// its (already paused) sessions are left to be replaced (or pruned) once the
+	// new sidecar container starts.
 	if sidecar, err := c.isMutagenComposeSidecar(ctx, container); err != nil {
 		return fmt.Errorf("unable to determine if container is sidecar: %w", err)
-	} else if sidecar {
+	} else if sidecar && !c.liaison.retainSessionsOnRemoval {
 		if err := c.liaison.terminateSessions(ctx, container); err != nil {
 			return fmt.Errorf("unable to terminate Mutagen sessions: %w", err)
 		}
@@ -88,6 +88,21 @@ func forwardingSessionCurrent(
 		session.ConfigurationDestination.Equal(specification.ConfigurationDestination)
 }
 
+// forwardingSessionReplaceable determines whether or not an existing forwarding
+// session created for a previous instance of the Mutagen Compose sidecar
+// container is equivalent to the specification for its creation, apart from the
+// sidecar container that it targets.
+func forwardingSessionReplaceable(
+	session *forwarding.Session,
+	specification *forwardingsvc.CreationSpecification,
+) bool {
+	return sidecarURLsEquivalent(session.Source, specification.Source) &&
+		sidecarURLsEquivalent(session.Destination, specification.Destination) &&
+		session.Configuration.Equal(specification.Configuration) &&
+		session.ConfigurationSource.Equal(specification.ConfigurationSource) &&
+		session.ConfigurationDestination.Equal(specification.ConfigurationDestination)
+}
+
 // forwardingCreateWithSpecification creates a forwarding session using the
 // provided forwarding service client, session specification, and prompter.
 func forwardingCreateWithSpecification(
@@ -101,7 +116,7 @@ func forwardingCreateWithSpecification(
 		Specification: specification,
 	})
 	if err != nil {
-		return "", grpcutil.PeelAwayRPCErrorLayer(err)
+		return "", peelAwayRPCErrorLayer(err)
 	} else if err = response.EnsureValid(); err != nil {
 		return "", fmt.Errorf("invalid create response received: %w", err)
 	}
@@ -1,15 +1,139 @@
 package mutagen
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/mutagen-io/mutagen/pkg/selection"
+)
+
 const (
 	// sessionSidecarLabelKey is the name of the label applied to Mutagen
 	// sessions to identify their associated Mutagen Compose sidecar container.
 	sessionSidecarLabelKey = "io.mutagen.compose.sidecar"
+	// sessionProjectLabelKey is the name of the label applied to Mutagen
+	// sessions to identify their associated Compose project (as encoded by
+	// encodeProjectName).
+	sessionProjectLabelKey = "io.mutagen.compose.project"
+	// sessionWorkingDirectoryLabelKey is the name of the label applied to
+	// Mutagen sessions to identify the working directory of their associated
+	// Compose project (as encoded by encodeWorkingDirectory).
+	sessionWorkingDirectoryLabelKey = "io.mutagen.compose.workdir"
+	// sessionTemporaryLabelKey is the name of the label applied to temporary
+	// Mutagen sessions (such as those used for seeding) to distinguish them
+	// from the sessions defined by a project. Its value is the purpose of the
+	// temporary session.
+	sessionTemporaryLabelKey = "io.mutagen.compose.temporary"
 )
 
-// chopSidecarIdentifier chops off the 128-bit prefix of a 256-bit sidecar
-// container identifier (encoded as a hex string) to make it fit into Mutagen
-// session label values (which are limited to 63 characters). The first 128 bits
-// of entropy should be more than sufficient to avoid collisions.
+// isStandardContainerIdentifier determines whether or not a container
+// identifier is in the standard Docker format, i.e. a 256-bit value encoded as
+// a 64-character lowercase hex string.
+func isStandardContainerIdentifier(identifier string) bool {
+	if len(identifier) != 64 {
+		return false
+	}
+	for _, r := range identifier {
+		if !(('0' <= r && r <= '9') || ('a' <= r && r <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// chopSidecarIdentifier encodes a sidecar container identifier to make it fit
+// into Mutagen session label values (which are limited to 63 characters). For
+// standard Docker container identifiers, it chops off the 128-bit prefix of the
+// identifier. The first 128 bits of entropy should be more than sufficient to
+// avoid collisions, but collisions can still be detected by verifying session
+// endpoints with ensureSidecarSessionOwnership. Non-standard identifiers (which
+// some Docker-compatible engines return) are instead hashed, with a prefix that
+// distinguishes them from chopped identifiers.
 func chopSidecarIdentifier(sidecarID string) string {
-	return sidecarID[:32]
+	if isStandardContainerIdentifier(sidecarID) {
+		return sidecarID[:32]
+	}
+	return "sha256-" + hashLabelValue(sidecarID)
+}
+
+// sidecarSessionSelection returns the selection criteria that identify Mutagen
+// sessions labeled as belonging to the specified sidecar container.
+func sidecarSessionSelection(sidecarID string) *selection.Selection {
+	return &selection.Selection{
+		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
+	}
+}
+
+// sidecarDefinedSessionSelection returns the selection criteria that identify
+// the Mutagen sessions defined by the project of the specified sidecar
+// container, i.e. the sidecar container's sessions excluding any temporary
+// sessions (which belong to the invocation that created them).
+func sidecarDefinedSessionSelection(sidecarID string) *selection.Selection {
+	return &selection.Selection{
+		LabelSelector: fmt.Sprintf("%s == %s, !%s",
+			sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID),
+			sessionTemporaryLabelKey,
+		),
+	}
+}
+
+// hashLabelValue computes a 128-bit hex-encoded hash of a value for use as a
+// Mutagen session label value.
+func hashLabelValue(value string) string {
+	digest := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(digest[:16])
+}
+
+// encodeProjectName encodes a Compose project name for use as a Mutagen session
+// label value. Project names are used verbatim if they constitute valid label
+// values (which they almost always will), otherwise they're hashed.
+func encodeProjectName(name string) string {
+	if selection.EnsureLabelValueValid(name) == nil {
+		return name
+	}
+	return hashLabelValue(name)
+}
+
+// encodeWorkingDirectory encodes a Compose project working directory for use as
+// a Mutagen session label value. Since paths can't generally be represented as
+// label values, the path is always hashed.
+func encodeWorkingDirectory(path string) string {
+	return hashLabelValue(path)
+}
+
+// sidecarProjectSessionSelection returns the selection criteria that identify
+// Mutagen sessions labeled as belonging to the project of a Mutagen Compose
+// sidecar container with the specified container labels, regardless of which
+// sidecar container instance they target. Sessions are matched by both project
+// name and working directory, since project names alone aren't unique.
+// Temporary sessions are excluded.
+func sidecarProjectSessionSelection(sidecarLabels map[string]string) *selection.Selection {
+	return &selection.Selection{
+		LabelSelector: fmt.Sprintf("%s == %s, %s == %s, !%s",
+			sessionProjectLabelKey, encodeProjectName(sidecarLabels[api.ProjectLabel]),
+			sessionWorkingDirectoryLabelKey, encodeWorkingDirectory(sidecarLabels[api.WorkingDirLabel]),
+			sessionTemporaryLabelKey,
+		),
+	}
+}
+
+// isTemporarySession determines whether or not a session with the specified
+// labels is a temporary session.
+func isTemporarySession(labels map[string]string) bool {
+	_, temporary := labels[sessionTemporaryLabelKey]
+	return temporary
+}
+
+// temporarySessionLabels returns a copy of a session's labels with the
+// temporary session label added using the specified purpose.
+func temporarySessionLabels(labels map[string]string, purpose string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		result[key] = value
+	}
+	result[sessionTemporaryLabelKey] = purpose
+	return result
 }
@@ -0,0 +1,32 @@
+package mutagen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestChopSidecarIdentifier tests chopSidecarIdentifier.
+func TestChopSidecarIdentifier(t *testing.T) {
+	standard := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testCases := []struct {
+		description string
+		identifier  string
+		expected    string
+	}{
+		{"standard identifier", standard, "0123456789abcdef0123456789abcdef"},
+		{"uppercase identifier", strings.ToUpper(standard), "sha256-" + hashLabelValue(strings.ToUpper(standard))},
+		{"short identifier", standard[:12], "sha256-" + hashLabelValue(standard[:12])},
+		{"long identifier", standard + "00", "sha256-" + hashLabelValue(standard+"00")},
+		{"non-hex identifier", strings.Repeat("z", 64), "sha256-" + hashLabelValue(strings.Repeat("z", 64))},
+		{"empty identifier", "", "sha256-" + hashLabelValue("")},
+	}
+	for _, testCase := range testCases {
+		chopped := chopSidecarIdentifier(testCase.identifier)
+		if chopped != testCase.expected {
+			t.Errorf("%s: result mismatch: %q != %q", testCase.description, chopped, testCase.expected)
+		}
+		if len(chopped) > 63 {
+			t.Errorf("%s: result too long for label value: %d characters", testCase.description, len(chopped))
+		}
+	}
+}
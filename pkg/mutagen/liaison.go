@@ -7,29 +7,29 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/pflag"
 
 	"github.com/docker/cli/cli/command"
 
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 
 	"github.com/compose-spec/compose-go/types"
 
 	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
 
 	"github.com/mitchellh/mapstructure"
 
-	"github.com/mutagen-io/mutagen/cmd/mutagen/daemon"
-	"github.com/mutagen-io/mutagen/cmd/mutagen/forward"
-	"github.com/mutagen-io/mutagen/cmd/mutagen/sync"
-
 	"github.com/mutagen-io/mutagen/pkg/forwarding"
 	"github.com/mutagen-io/mutagen/pkg/grpcutil"
 	"github.com/mutagen-io/mutagen/pkg/mutagen"
 	"github.com/mutagen-io/mutagen/pkg/selection"
 	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
-	promptingsvc "github.com/mutagen-io/mutagen/pkg/service/prompting"
 	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
 	"github.com/mutagen-io/mutagen/pkg/synchronization"
 	"github.com/mutagen-io/mutagen/pkg/url"
@@ -55,12 +55,50 @@ type Liaison struct {
 	// mutagenService is the Mutagen Compose sidecar service definition. It is
 	// initialized by calling processProject.
 	mutagenService types.ServiceConfig
+	// sidecarUpgradeDisabled indicates whether or not automatic recreation of
+	// outdated Mutagen Compose sidecar containers has been disabled. It is
+	// initialized by calling processProject.
+	sidecarUpgradeDisabled bool
+	// retainSessionsOnRemoval indicates whether or not Mutagen sessions should
+	// be retained (rather than terminated) when a Mutagen Compose sidecar
+	// container is removed. This is set during operations where sidecar removal
+	// indicates recreation, in which case the sessions will be replaced or
+	// pruned when the new sidecar container starts.
+	retainSessionsOnRemoval bool
+	// projectDataDirectory is the project-scoped Mutagen data directory, if
+	// any. It is initialized by calling processProject and is updated whenever
+	// a Mutagen Compose sidecar container is inspected.
+	projectDataDirectory string
+	// sidecarProjectName is the name of the project to which the most recently
+	// inspected Mutagen Compose sidecar container belongs.
+	sidecarProjectName string
+	// projectLocksLock serializes access to projectLocks.
+	projectLocksLock sync.Mutex
+	// projectLocks are the project locks currently held, keyed by lock path.
+	projectLocks map[string]*projectLock
+	// projectOperationTimeout is the operation timeout specified by the
+	// project, or 0 if none was specified. It is initialized by calling
+	// processProject.
+	projectOperationTimeout time.Duration
+	// projectLabels are the project-level labels applied to Mutagen sessions.
+	// This map is initialized by calling processProject.
+	projectLabels map[string]string
 	// forwarding are the forwarding session specifications. This map is
 	// initialized by calling processProject.
 	forwarding map[string]*forwardingsvc.CreationSpecification
 	// synchronization are the synchronization session specifications. This map
 	// is initialized by calling processProject.
 	synchronization map[string]*synchronizationsvc.CreationSpecification
+	// synchronizationSeeds maps synchronization session names to their initial
+	// seeding directions. Sessions without seeding are omitted. This map is
+	// initialized by calling processProject.
+	synchronizationSeeds map[string]string
+	// synchronizationOneshot is the set of synchronization session names with
+	// a oneshot lifecycle. This map is initialized by calling processProject.
+	synchronizationOneshot map[string]bool
+	// daemon is the Mutagen daemon connection and prompter shared by all
+	// operations. It is lazily initialized and closed by Shutdown.
+	daemon daemonClient
 }
 
 // RegisterDockerCLI registers the associated Docker CLI instance.
@@ -203,6 +241,7 @@ func (l *Liaison) processProject(project *types.Project) error {
 	defaultConfigurationSynchronization := &synchronization.Configuration{}
 	defaultConfigurationAlpha := &synchronization.Configuration{}
 	defaultConfigurationBeta := &synchronization.Configuration{}
+	var defaultSeed, defaultLifecycle string
 	if defaults, ok := xMutagen.Synchronization["defaults"]; ok {
 		if defaults.Alpha != "" {
 			return errors.New("alpha URL not allowed in default synchronization configuration")
@@ -221,6 +260,14 @@ func (l *Liaison) processProject(project *types.Project) error {
 		if err := defaultConfigurationBeta.EnsureValid(true); err != nil {
 			return fmt.Errorf("invalid default synchronization beta configuration: %w", err)
 		}
+		if !isValidSynchronizationSeed(defaults.Seed) {
+			return fmt.Errorf("invalid default synchronization seed specification: %s", defaults.Seed)
+		}
+		defaultSeed = defaults.Seed
+		if !isValidSynchronizationLifecycle(defaults.Lifecycle) {
+			return fmt.Errorf("invalid default synchronization lifecycle specification: %s", defaults.Lifecycle)
+		}
+		defaultLifecycle = defaults.Lifecycle
 		delete(xMutagen.Synchronization, "defaults")
 	}
 
@@ -308,6 +355,8 @@ func (l *Liaison) processProject(project *types.Project) error {
 	// Validate synchronization configurations, convert them to session creation
 	// specifications, and extract volume dependencies for the Mutagen service.
 	synchronizationSpecifications := make(map[string]*synchronizationsvc.CreationSpecification)
+	synchronizationSeeds := make(map[string]string)
+	synchronizationOneshot := make(map[string]bool)
 	volumeDependencies := make(map[string]bool)
 	for name, session := range xMutagen.Synchronization {
 		// Verify that the name is valid.
@@ -400,6 +449,32 @@ func (l *Liaison) processProject(project *types.Project) error {
 		}
 		betaConfiguration = synchronization.MergeConfigurations(defaultConfigurationBeta, betaConfiguration)
 
+		// Compute and validate the seeding direction. Seeding is only
+		// meaningful for bidirectional synchronization modes.
+		seed := session.Seed
+		if seed == "" {
+			seed = defaultSeed
+		}
+		if !isValidSynchronizationSeed(seed) {
+			return fmt.Errorf("invalid synchronization seed specification for %s: %s", name, seed)
+		} else if seed == synchronizationSeedAlpha || seed == synchronizationSeedBeta {
+			if isOneWaySynchronizationMode(configuration.SynchronizationMode) {
+				return fmt.Errorf("seeding not supported with one-way synchronization mode for %s", name)
+			}
+			synchronizationSeeds[name] = seed
+		}
+
+		// Compute and validate the session lifecycle.
+		lifecycle := session.Lifecycle
+		if lifecycle == "" {
+			lifecycle = defaultLifecycle
+		}
+		if !isValidSynchronizationLifecycle(lifecycle) {
+			return fmt.Errorf("invalid synchronization lifecycle specification for %s: %s", name, lifecycle)
+		} else if lifecycle == synchronizationLifecycleOneshot {
+			synchronizationOneshot[name] = true
+		}
+
 		// Record the specification.
 		synchronizationSpecifications[name] = &synchronizationsvc.CreationSpecification{
 			Alpha:              alphaURL,
@@ -516,19 +591,86 @@ func (l *Liaison) processProject(project *types.Project) error {
 	if xMutagen.Sidecar.ContainerName != "" {
 		l.mutagenService.ContainerName = xMutagen.Sidecar.ContainerName
 	}
+	if !isValidUpgradePolicy(xMutagen.Sidecar.Upgrade) {
+		return fmt.Errorf("invalid sidecar upgrade policy specification: %s", xMutagen.Sidecar.Upgrade)
+	}
+	l.sidecarUpgradeDisabled = xMutagen.Sidecar.Upgrade == "never"
+
+	// Process daemon configuration. If a project-scoped data directory is in
+	// use, then record it on the sidecar container so that operations that
+	// don't load the project can still target the correct daemon.
+	if xMutagen.Daemon.DataDirectory != "" {
+		l.projectDataDirectory = xMutagen.Daemon.DataDirectory
+		if !filepath.IsAbs(l.projectDataDirectory) {
+			l.projectDataDirectory = filepath.Join(project.WorkingDir, l.projectDataDirectory)
+		}
+	}
+	if dataDirectory, err := l.dataDirectory(); err != nil {
+		return err
+	} else if dataDirectory != "" {
+		l.mutagenService.Labels[sidecarDataDirectoryLabelKey] = dataDirectory
+	}
+	if xMutagen.Daemon.OperationTimeout != "" {
+		timeout, err := parseOperationTimeout(xMutagen.Daemon.OperationTimeout)
+		if err != nil {
+			return err
+		}
+		l.projectOperationTimeout = timeout
+	}
+
+	// Compute project-level session labels.
+	l.projectLabels = map[string]string{
+		sessionProjectLabelKey:          encodeProjectName(project.Name),
+		sessionWorkingDirectoryLabelKey: encodeWorkingDirectory(project.WorkingDir),
+	}
 
 	// Store session specifications.
 	l.forwarding = forwardingSpecifications
 	l.synchronization = synchronizationSpecifications
+	l.synchronizationSeeds = synchronizationSeeds
+	l.synchronizationOneshot = synchronizationOneshot
 
 	// Success.
 	return nil
 }
 
+// prepareSpecifications converts sidecar URLs in the session specifications to
+// concrete Docker URLs targeting the specified sidecar container and adds
+// session labels. It must only be called after processProject.
+func (l *Liaison) prepareSpecifications(sidecarID string) {
+	// Compute the session labels.
+	labels := map[string]string{
+		sessionSidecarLabelKey: chopSidecarIdentifier(sidecarID),
+	}
+	for key, value := range l.projectLabels {
+		labels[key] = value
+	}
+
+	// Convert sidecar URLs to concrete Docker URLs and add labels.
+	for _, specification := range l.forwarding {
+		reifySidecarURLIfNecessary(specification.Source, l.dockerFlags, l.dockerCLI, sidecarID)
+		reifySidecarURLIfNecessary(specification.Destination, l.dockerFlags, l.dockerCLI, sidecarID)
+		specification.Labels = labels
+	}
+	for _, specification := range l.synchronization {
+		reifySidecarURLIfNecessary(specification.Alpha, l.dockerFlags, l.dockerCLI, sidecarID)
+		reifySidecarURLIfNecessary(specification.Beta, l.dockerFlags, l.dockerCLI, sidecarID)
+		specification.Labels = labels
+	}
+}
+
 // reconcileSessions performs Mutagen session reconciliation for the project
 // using the specified sidecar container ID as the target identifier. It also
 // ensures that all sessions are unpaused.
 func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error {
+	// Apply the operation timeout and defer cancellation of the operation
+	// context.
+	ctx, cancel, err := l.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	// Create a Mutagen status updater, start the Mutagen status update, and
 	// defer its finalization.
 	status := newStatusUpdater(ctx, "Mutagen")
@@ -542,60 +684,58 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 		}
 	}()
 
-	// Convert sidecar URLs to concrete Docker URLs and add sidecar ID labels.
-	for _, specification := range l.forwarding {
-		reifySidecarURLIfNecessary(specification.Source, l.dockerFlags, l.dockerCLI, sidecarID)
-		reifySidecarURLIfNecessary(specification.Destination, l.dockerFlags, l.dockerCLI, sidecarID)
-		specification.Labels = map[string]string{
-			sessionSidecarLabelKey: chopSidecarIdentifier(sidecarID),
-		}
-	}
-	for _, specification := range l.synchronization {
-		reifySidecarURLIfNecessary(specification.Alpha, l.dockerFlags, l.dockerCLI, sidecarID)
-		reifySidecarURLIfNecessary(specification.Beta, l.dockerFlags, l.dockerCLI, sidecarID)
-		specification.Labels = map[string]string{
-			sessionSidecarLabelKey: chopSidecarIdentifier(sidecarID),
-		}
+	// Lock the project (to serialize operations with other Mutagen Compose
+	// invocations) and defer release of the lock.
+	ctx, unlock, err := l.lockProject(ctx, status, l.sidecarProjectName)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to lock project: %w", err)
+		return statusErr
 	}
+	defer unlock()
+
+	// Convert sidecar URLs to concrete Docker URLs and add labels.
+	l.prepareSpecifications(sidecarID)
 
-	// Connect to the Mutagen daemon and defer closure of the connection.
+	// Connect to the Mutagen daemon.
 	status.working("Connecting to Mutagen daemon")
-	daemonConnection, err := daemon.Connect(true, true)
+	daemonConnection, err := l.sharedDaemonConnection(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
 		return statusErr
 	}
-	defer daemonConnection.Close()
 
-	// Initiate message-only prompting via the status updater and defer its
-	// termination.
-	promptingCtx, promptingCancel := context.WithCancel(ctx)
-	prompter, promptingErrors, err := promptingsvc.Host(
-		promptingCtx, promptingsvc.NewPromptingClient(daemonConnection),
-		status, false,
-	)
-	defer func() {
-		promptingCancel()
-		<-promptingErrors
-	}()
+	// Relay prompting to the status updater and defer the relay's removal.
+	prompter, releasePrompter, err := l.sharedPrompter(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
 		return statusErr
 	}
+	defer releasePrompter()
 
 	// Create service clients.
 	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
 	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
 
 	// Create the session selection criteria.
-	projectSelection := &selection.Selection{
-		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
-	}
+	projectSelection := sidecarSessionSelection(sidecarID)
+	status.interruptionDetails = l.describeSessionStates(projectSelection)
+
+	// Track changes made during reconciliation and defer their rollback in the
+	// event of failure.
+	changes := &reconciliationChanges{}
+	defer func() {
+		if statusErr != nil {
+			l.rollbackReconciliation(ctx, forwardingService, synchronizationService, prompter, changes)
+		}
+	}()
+
+	// Start the reconciliation summary.
+	summary := newReconciliationSummary("planning")
 
 	// Query existing forwarding sessions.
 	status.working("Querying existing forwarding sessions")
 	forwardingListRequest := &forwardingsvc.ListRequest{Selection: projectSelection}
-	forwardingListResponse, err := forwardingService.List(context.Background(), forwardingListRequest)
+	forwardingListResponse, err := forwardingService.List(ctx, forwardingListRequest)
 	if err != nil {
 		statusErr = fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
 		return statusErr
@@ -607,7 +747,7 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 	// Query existing synchronization sessions.
 	status.working("Querying existing synchronization sessions")
 	synchronizationListRequest := &synchronizationsvc.ListRequest{Selection: projectSelection}
-	synchronizationListResponse, err := synchronizationService.List(context.Background(), synchronizationListRequest)
+	synchronizationListResponse, err := synchronizationService.List(ctx, synchronizationListRequest)
 	if err != nil {
 		statusErr = fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
 		return statusErr
@@ -616,6 +756,49 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 		return statusErr
 	}
 
+	// Verify that the existing sessions actually belong to the sidecar.
+	status.working("Verifying existing session ownership")
+	if err := checkSidecarSessionOwnership(
+		forwardingListResponse.SessionStates, synchronizationListResponse.SessionStates, sidecarID,
+	); err != nil {
+		statusErr = err
+		return statusErr
+	}
+
+	// Create the selection criteria for sessions created for previous instances
+	// of the sidecar container for this project.
+	previousSelection := &selection.Selection{
+		LabelSelector: fmt.Sprintf("%s == %s, %s == %s, %s != %s",
+			sessionProjectLabelKey, l.projectLabels[sessionProjectLabelKey],
+			sessionWorkingDirectoryLabelKey, l.projectLabels[sessionWorkingDirectoryLabelKey],
+			sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID),
+		),
+	}
+
+	// Query forwarding sessions from previous sidecar instances.
+	status.working("Querying previous forwarding sessions")
+	forwardingPreviousRequest := &forwardingsvc.ListRequest{Selection: previousSelection}
+	forwardingPreviousResponse, err := forwardingService.List(ctx, forwardingPreviousRequest)
+	if err != nil {
+		statusErr = fmt.Errorf("previous forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		return statusErr
+	} else if err = forwardingPreviousResponse.EnsureValid(); err != nil {
+		statusErr = fmt.Errorf("invalid previous forwarding session listing response received: %w", err)
+		return statusErr
+	}
+
+	// Query synchronization sessions from previous sidecar instances.
+	status.working("Querying previous synchronization sessions")
+	synchronizationPreviousRequest := &synchronizationsvc.ListRequest{Selection: previousSelection}
+	synchronizationPreviousResponse, err := synchronizationService.List(ctx, synchronizationPreviousRequest)
+	if err != nil {
+		statusErr = fmt.Errorf("previous synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		return statusErr
+	} else if err = synchronizationPreviousResponse.EnsureValid(); err != nil {
+		statusErr = fmt.Errorf("invalid previous synchronization session listing response received: %w", err)
+		return statusErr
+	}
+
 	// Identify orphan forwarding sessions with no corresponding definition, as
 	// well as any duplicate forwarding sessions. At the same time, construct a
 	// map from session name to existing session.
@@ -625,8 +808,10 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 	for _, state := range forwardingListResponse.SessionStates {
 		if _, defined := l.forwarding[state.Session.Name]; !defined {
 			forwardingPruneList = append(forwardingPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindForwarding, Name: state.Session.Name, Reason: "orphaned"})
 		} else if _, duplicated := forwardingNameToSession[state.Session.Name]; duplicated {
 			forwardingPruneList = append(forwardingPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindForwarding, Name: state.Session.Name, Reason: "duplicate"})
 		} else {
 			forwardingNameToSession[state.Session.Name] = state.Session
 		}
@@ -634,45 +819,124 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 
 	// Identify orphan synchronization sessions with no corresponding
 	// definition, as well as any duplicate synchronization sessions. At the
-	// same time, construct a map from session name to existing session.
+	// same time, construct a map from session name to existing session. Any
+	// temporary sessions are the remnants of an interrupted operation and are
+	// pruned without being matched to definitions.
 	status.working("Identifying orphan synchronization sessions")
 	var synchronizationPruneList []string
 	synchronizationNameToSession := make(map[string]*synchronization.Session)
 	for _, state := range synchronizationListResponse.SessionStates {
-		if _, defined := l.synchronization[state.Session.Name]; !defined {
+		if isTemporarySession(state.Session.Labels) {
+			synchronizationPruneList = append(synchronizationPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindSynchronization, Name: state.Session.Name, Reason: "interrupted"})
+		} else if _, defined := l.synchronization[state.Session.Name]; !defined {
 			synchronizationPruneList = append(synchronizationPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindSynchronization, Name: state.Session.Name, Reason: "orphaned"})
 		} else if _, duplicated := synchronizationNameToSession[state.Session.Name]; duplicated {
 			synchronizationPruneList = append(synchronizationPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindSynchronization, Name: state.Session.Name, Reason: "duplicate"})
 		} else {
 			synchronizationNameToSession[state.Session.Name] = state.Session
 		}
 	}
 
+	// Identify forwarding sessions from previous sidecar instances that are
+	// equivalent to their specifications and can thus be replaced by sessions
+	// targeting the current sidecar instance. Mutagen session endpoints are
+	// immutable, so no session state is carried over: a replacement session is
+	// created from scratch, and the previous session is only terminated once
+	// its replacement exists, ensuring that it isn't lost if creation fails.
+	// Any previous sessions that can't be replaced are pruned.
+	status.working("Identifying replaceable forwarding sessions")
+	forwardingReplaceable := make(map[string]string)
+	var forwardingRetireList []string
+	for _, state := range forwardingPreviousResponse.SessionStates {
+		name := state.Session.Name
+		specification, defined := l.forwarding[name]
+		_, current := forwardingNameToSession[name]
+		_, replaced := forwardingReplaceable[name]
+		if defined && !current && !replaced && forwardingSessionReplaceable(state.Session, specification) {
+			forwardingReplaceable[name] = state.Session.Identifier
+			forwardingRetireList = append(forwardingRetireList, state.Session.Identifier)
+		} else {
+			forwardingPruneList = append(forwardingPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindForwarding, Name: name, Reason: "previous sidecar"})
+		}
+	}
+
+	// Identify synchronization sessions from previous sidecar instances that
+	// can be replaced using the same strategy. Replacement sessions aren't
+	// seeded, since the volume content that they target is retained.
+	status.working("Identifying replaceable synchronization sessions")
+	synchronizationReplaceable := make(map[string]string)
+	var synchronizationRetireList []string
+	for _, state := range synchronizationPreviousResponse.SessionStates {
+		name := state.Session.Name
+		specification, defined := l.synchronization[name]
+		_, current := synchronizationNameToSession[name]
+		_, replaced := synchronizationReplaceable[name]
+		temporary := isTemporarySession(state.Session.Labels)
+		if defined && !current && !replaced && !temporary && synchronizationSessionReplaceable(state.Session, specification) {
+			synchronizationReplaceable[name] = state.Session.Identifier
+			synchronizationRetireList = append(synchronizationRetireList, state.Session.Identifier)
+		} else {
+			synchronizationPruneList = append(synchronizationPruneList, state.Session.Identifier)
+			summary.Pruned = append(summary.Pruned, SessionChange{Kind: SessionKindSynchronization, Name: name, Reason: "previous sidecar"})
+		}
+	}
+
 	// Identify forwarding sessions that need to be created or recreated.
 	status.working("Identifying missing and stale forwarding sessions")
 	var forwardingCreateSpecifications []*forwardingsvc.CreationSpecification
 	for name, specification := range l.forwarding {
 		if existing, ok := forwardingNameToSession[name]; !ok {
 			forwardingCreateSpecifications = append(forwardingCreateSpecifications, specification)
+			if _, replaceable := forwardingReplaceable[name]; replaceable {
+				summary.Recreated = append(summary.Recreated, SessionChange{Kind: SessionKindForwarding, Name: name, Reason: "sidecar replaced"})
+			} else {
+				summary.Created = append(summary.Created, SessionChange{Kind: SessionKindForwarding, Name: name})
+			}
 		} else if !forwardingSessionCurrent(existing, specification) {
 			forwardingPruneList = append(forwardingPruneList, existing.Identifier)
 			forwardingCreateSpecifications = append(forwardingCreateSpecifications, specification)
+			summary.Recreated = append(summary.Recreated, SessionChange{Kind: SessionKindForwarding, Name: name, Reason: "configuration changed"})
+		} else if existing.Paused {
+			summary.Resumed = append(summary.Resumed, SessionChange{Kind: SessionKindForwarding, Name: name})
 		}
 	}
 
 	// Identify synchronization sessions that need to be created or recreated.
+	// Oneshot sessions are always recreated (though any existing instances
+	// would only be the remnants of an interrupted reconciliation).
 	status.working("Identifying missing and stale synchronization sessions")
 	var synchronizationCreateSpecifications []*synchronizationsvc.CreationSpecification
+	synchronizationSeedable := make(map[string]bool)
 	for name, specification := range l.synchronization {
 		if existing, ok := synchronizationNameToSession[name]; !ok {
 			synchronizationCreateSpecifications = append(synchronizationCreateSpecifications, specification)
-		} else if !synchronizationSessionCurrent(existing, specification) {
+			if _, replaceable := synchronizationReplaceable[name]; !replaceable {
+				synchronizationSeedable[name] = true
+				summary.Created = append(summary.Created, SessionChange{Kind: SessionKindSynchronization, Name: name})
+			} else {
+				summary.Recreated = append(summary.Recreated, SessionChange{Kind: SessionKindSynchronization, Name: name, Reason: "sidecar replaced"})
+			}
+		} else if l.synchronizationOneshot[name] || !synchronizationSessionCurrent(existing, specification) {
 			synchronizationPruneList = append(synchronizationPruneList, existing.Identifier)
 			synchronizationCreateSpecifications = append(synchronizationCreateSpecifications, specification)
+			reason := "configuration changed"
+			if l.synchronizationOneshot[name] {
+				reason = "oneshot"
+			}
+			summary.Recreated = append(summary.Recreated, SessionChange{Kind: SessionKindSynchronization, Name: name, Reason: reason})
+		} else if existing.Paused {
+			summary.Resumed = append(summary.Resumed, SessionChange{Kind: SessionKindSynchronization, Name: name})
 		}
 	}
 
 	// Prune orphaned and stale forwarding sessions.
+	if len(forwardingPruneList) > 0 || len(synchronizationPruneList) > 0 {
+		summary.phase("pruning")
+	}
 	if len(forwardingPruneList) > 0 {
 		status.working("Pruning stale Mutagen forwarding sessions")
 		pruneSelection := &selection.Selection{Specifications: forwardingPruneList}
@@ -680,6 +944,7 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 			statusErr = fmt.Errorf("unable to prune orphaned/duplicate/stale forwarding sessions: %w", err)
 			return statusErr
 		}
+		changes.pruned += len(forwardingPruneList)
 	}
 
 	// Prune orphaned and stale synchronization sessions.
@@ -690,6 +955,7 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 			statusErr = fmt.Errorf("unable to prune orphaned/duplicate/stale synchronization sessions: %w", err)
 			return statusErr
 		}
+		changes.pruned += len(synchronizationPruneList)
 	}
 
 	// Ensure that all existing sessions are unpaused and connected. This is a
@@ -697,6 +963,7 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 	// this in case the Mutagen service is being restarted after a system
 	// shutdown or stop operation, in which case sessions may be waiting to
 	// reconnect or paused, respectively.
+	summary.phase("resuming")
 	status.working("Resuming Mutagen forwarding sessions")
 	if err := forwardingResumeWithSelection(ctx, forwardingService, prompter, projectSelection); err != nil {
 		statusErr = fmt.Errorf("forwarding resumption failed: %w", err)
@@ -709,28 +976,98 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 	}
 
 	// Create forwarding sessions.
+	if len(forwardingCreateSpecifications) > 0 || len(synchronizationCreateSpecifications) > 0 {
+		summary.phase("creating")
+	}
 	for _, specification := range forwardingCreateSpecifications {
-		status.working(fmt.Sprintf("Creating Mutagen forwarding session \"%s\"", specification.Name))
-		if _, err := forwardingCreateWithSpecification(ctx, forwardingService, prompter, specification); err != nil {
+		if _, replaceable := forwardingReplaceable[specification.Name]; replaceable {
+			status.working(fmt.Sprintf("Recreating Mutagen forwarding session \"%s\" for new sidecar", specification.Name))
+		} else {
+			status.working(fmt.Sprintf("Creating Mutagen forwarding session \"%s\"", specification.Name))
+		}
+		var session string
+		if err := retryTransient(ctx, status, "forwarding session creation", func() (err error) {
+			session, err = forwardingCreateWithSpecification(ctx, forwardingService, prompter, specification)
+			return err
+		}); err != nil {
 			statusErr = fmt.Errorf("unable to create forwarding session (%s): %w", specification.Name, err)
 			return statusErr
 		}
+		changes.createdForwarding(specification.Name, session)
 	}
 
 	// Create synchronization sessions.
-	var newSynchronizationSessions []string
+	var newSynchronizationSessions, oneshotSynchronizationSessions []string
 	for _, specification := range synchronizationCreateSpecifications {
-		status.working(fmt.Sprintf("Creating Mutagen synchronization session \"%s\"", specification.Name))
-		if s, err := synchronizationCreateWithSpecification(ctx, synchronizationService, prompter, specification); err != nil {
+		if _, replaceable := synchronizationReplaceable[specification.Name]; replaceable {
+			status.working(fmt.Sprintf("Recreating Mutagen synchronization session \"%s\" for new sidecar", specification.Name))
+		} else {
+			status.working(fmt.Sprintf("Creating Mutagen synchronization session \"%s\"", specification.Name))
+		}
+		if seed, ok := l.synchronizationSeeds[specification.Name]; ok && synchronizationSeedable[specification.Name] {
+			status.working(fmt.Sprintf("Seeding Mutagen synchronization session \"%s\" from %s", specification.Name, seed))
+			if err := synchronizationRunOnceWithSpecification(ctx, status, synchronizationService, prompter,
+				synchronizationSeedSpecification(specification, seed),
+			); err != nil {
+				statusErr = fmt.Errorf("unable to seed synchronization session (%s): %w", specification.Name, err)
+				return statusErr
+			}
+			status.working(fmt.Sprintf("Creating Mutagen synchronization session \"%s\"", specification.Name))
+		}
+		var session string
+		if err := retryTransient(ctx, status, "synchronization session creation", func() (err error) {
+			session, err = synchronizationCreateWithSpecification(ctx, synchronizationService, prompter, specification)
+			return err
+		}); err != nil {
 			statusErr = fmt.Errorf("unable to create synchronization session (%s): %w", specification.Name, err)
 			return statusErr
-		} else {
-			newSynchronizationSessions = append(newSynchronizationSessions, s)
+		}
+		changes.createdSynchronization(specification.Name, session)
+		newSynchronizationSessions = append(newSynchronizationSessions, session)
+		summary.Flushed = append(summary.Flushed, SessionChange{Kind: SessionKindSynchronization, Name: specification.Name})
+		if l.synchronizationOneshot[specification.Name] {
+			oneshotSynchronizationSessions = append(oneshotSynchronizationSessions, session)
+		}
+	}
+
+	// Report the states of the project's sessions individually for the
+	// remainder of reconciliation.
+	sessionProgress := trackSessionProgress(ctx, forwardingService, synchronizationService, sidecarSessionSelection(sidecarID), nil)
+	defer sessionProgress.stop()
+
+	// Terminate forwarding sessions from previous sidecar instances that have
+	// now been replaced. Once retirement begins, created sessions can no longer
+	// be rolled back without losing the sessions that they replace, so they're
+	// marked as committed. If there's nothing to retire, then created sessions
+	// can still be rolled back.
+	if len(forwardingRetireList) > 0 || len(synchronizationRetireList) > 0 {
+		summary.phase("retiring")
+	}
+	if len(forwardingRetireList) > 0 {
+		status.working("Retiring replaced Mutagen forwarding sessions")
+		changes.committed = true
+		retireSelection := &selection.Selection{Specifications: forwardingRetireList}
+		if err := forwardingTerminateWithSelection(ctx, forwardingService, prompter, retireSelection); err != nil {
+			statusErr = fmt.Errorf("unable to terminate replaced forwarding sessions: %w", err)
+			return statusErr
+		}
+	}
+
+	// Terminate synchronization sessions from previous sidecar instances that
+	// have now been replaced.
+	if len(synchronizationRetireList) > 0 {
+		status.working("Retiring replaced Mutagen synchronization sessions")
+		changes.committed = true
+		retireSelection := &selection.Selection{Specifications: synchronizationRetireList}
+		if err := synchronizationTerminateWithSelection(ctx, synchronizationService, prompter, retireSelection); err != nil {
+			statusErr = fmt.Errorf("unable to terminate replaced synchronization sessions: %w", err)
+			return statusErr
 		}
 	}
 
 	// Flush newly created synchronization sessions.
 	if len(newSynchronizationSessions) > 0 {
+		summary.phase("flushing")
 		status.working("Flushing Mutagen synchronization sessions")
 		flushSelection := &selection.Selection{Specifications: newSynchronizationSessions}
 		if err := synchronizationFlushWithSelection(ctx, synchronizationService, prompter, flushSelection); err != nil {
@@ -739,36 +1076,24 @@ func (l *Liaison) reconcileSessions(ctx context.Context, sidecarID string) error
 		}
 	}
 
-	// Success.
-	return nil
-}
-
-// listSessions lists Mutagen sessions for the project using the specified
-// sidecar container ID as the target identifier.
-func (l *Liaison) listSessions(ctx context.Context, sidecarID string) error {
-	// Connect to the Mutagen daemon and defer closure of the connection.
-	daemonConnection, err := daemon.Connect(true, true)
-	if err != nil {
-		return fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
-	}
-	defer daemonConnection.Close()
-
-	// Create the session selection criteria.
-	projectSelection := &selection.Selection{
-		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
-	}
-
-	// Perform forwarding session listing.
-	fmt.Println("Forwarding sessions")
-	if err := forward.ListWithSelection(daemonConnection, projectSelection, false); err != nil {
-		return fmt.Errorf("forwarding listing failed: %w", err)
+	// Terminate oneshot synchronization sessions now that they've completed
+	// their synchronization cycle.
+	if len(oneshotSynchronizationSessions) > 0 {
+		summary.phase("finalizing")
+		status.working("Terminating oneshot Mutagen synchronization sessions")
+		oneshotSelection := &selection.Selection{Specifications: oneshotSynchronizationSessions}
+		if err := synchronizationTerminateWithSelection(ctx, synchronizationService, prompter, oneshotSelection); err != nil {
+			statusErr = fmt.Errorf("unable to terminate oneshot synchronization sessions: %w", err)
+			return statusErr
+		}
 	}
 
-	// Perform synchronization session listing.
-	fmt.Println("Synchronization sessions")
-	if err := sync.ListWithSelection(daemonConnection, projectSelection, false); err != nil {
-		return fmt.Errorf("synchronization listing failed: %w", err)
-	}
+	// Report the reconciliation summary, including the staging observed while
+	// flushing.
+	sessionProgress.stop()
+	summary.StagedFiles, summary.StagedBytes = sessionProgress.stagedTotals(newSynchronizationSessions)
+	summary.finish()
+	summary.report(status.writer)
 
 	// Success.
 	return nil
@@ -777,6 +1102,14 @@ func (l *Liaison) listSessions(ctx context.Context, sidecarID string) error {
 // pauseSessions pauses Mutagen sessions for the project using the specified
 // sidecar container ID as the target identifier.
 func (l *Liaison) pauseSessions(ctx context.Context, sidecarID string) error {
+	// Apply the operation timeout and defer cancellation of the operation
+	// context.
+	ctx, cancel, err := l.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	// Create a Mutagen status updater, start the Mutagen status update, and
 	// defer its finalization.
 	status := newStatusUpdater(ctx, "Mutagen")
@@ -790,38 +1123,44 @@ func (l *Liaison) pauseSessions(ctx context.Context, sidecarID string) error {
 		}
 	}()
 
-	// Connect to the Mutagen daemon and defer closure of the connection.
+	// Lock the project (to serialize operations with other Mutagen Compose
+	// invocations) and defer release of the lock.
+	ctx, unlock, err := l.lockProject(ctx, status, l.sidecarProjectName)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to lock project: %w", err)
+		return statusErr
+	}
+	defer unlock()
+
+	// Connect to the Mutagen daemon.
 	status.working("Connecting to Mutagen daemon")
-	daemonConnection, err := daemon.Connect(true, true)
+	daemonConnection, err := l.sharedDaemonConnection(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
 		return statusErr
 	}
-	defer daemonConnection.Close()
 
-	// Initiate message-only prompting via the status updater and defer its
-	// termination.
-	promptingCtx, promptingCancel := context.WithCancel(ctx)
-	prompter, promptingErrors, err := promptingsvc.Host(
-		promptingCtx, promptingsvc.NewPromptingClient(daemonConnection),
-		status, false,
-	)
-	defer func() {
-		promptingCancel()
-		<-promptingErrors
-	}()
+	// Relay prompting to the status updater and defer the relay's removal.
+	prompter, releasePrompter, err := l.sharedPrompter(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
 		return statusErr
 	}
+	defer releasePrompter()
 
 	// Create service clients.
 	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
 	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
 
 	// Create the session selection criteria.
-	projectSelection := &selection.Selection{
-		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
+	projectSelection := sidecarSessionSelection(sidecarID)
+	status.interruptionDetails = l.describeSessionStates(projectSelection)
+
+	// Verify that the selected sessions actually belong to the sidecar.
+	status.working("Verifying session ownership")
+	if err := ensureSidecarSessionOwnership(ctx, forwardingService, synchronizationService, projectSelection, sidecarID); err != nil {
+		statusErr = err
+		return statusErr
 	}
 
 	// Perform forwarding session pausing.
@@ -845,6 +1184,14 @@ func (l *Liaison) pauseSessions(ctx context.Context, sidecarID string) error {
 // resumeSessions resumes Mutagen sessions for the project using the specified
 // sidecar container ID as the target identifier.
 func (l *Liaison) resumeSessions(ctx context.Context, sidecarID string) error {
+	// Apply the operation timeout and defer cancellation of the operation
+	// context.
+	ctx, cancel, err := l.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	// Create a Mutagen status updater, start the Mutagen status update, and
 	// defer its finalization.
 	status := newStatusUpdater(ctx, "Mutagen")
@@ -858,38 +1205,44 @@ func (l *Liaison) resumeSessions(ctx context.Context, sidecarID string) error {
 		}
 	}()
 
-	// Connect to the Mutagen daemon and defer closure of the connection.
+	// Lock the project (to serialize operations with other Mutagen Compose
+	// invocations) and defer release of the lock.
+	ctx, unlock, err := l.lockProject(ctx, status, l.sidecarProjectName)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to lock project: %w", err)
+		return statusErr
+	}
+	defer unlock()
+
+	// Connect to the Mutagen daemon.
 	status.working("Connecting to Mutagen daemon")
-	daemonConnection, err := daemon.Connect(true, true)
+	daemonConnection, err := l.sharedDaemonConnection(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
 		return statusErr
 	}
-	defer daemonConnection.Close()
 
-	// Initiate message-only prompting via the status updater and defer its
-	// termination.
-	promptingCtx, promptingCancel := context.WithCancel(ctx)
-	prompter, promptingErrors, err := promptingsvc.Host(
-		promptingCtx, promptingsvc.NewPromptingClient(daemonConnection),
-		status, false,
-	)
-	defer func() {
-		promptingCancel()
-		<-promptingErrors
-	}()
+	// Relay prompting to the status updater and defer the relay's removal.
+	prompter, releasePrompter, err := l.sharedPrompter(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
 		return statusErr
 	}
+	defer releasePrompter()
 
 	// Create service clients.
 	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
 	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
 
 	// Create the session selection criteria.
-	projectSelection := &selection.Selection{
-		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
+	projectSelection := sidecarSessionSelection(sidecarID)
+	status.interruptionDetails = l.describeSessionStates(projectSelection)
+
+	// Verify that the selected sessions actually belong to the sidecar.
+	status.working("Verifying session ownership")
+	if err := ensureSidecarSessionOwnership(ctx, forwardingService, synchronizationService, projectSelection, sidecarID); err != nil {
+		statusErr = err
+		return statusErr
 	}
 
 	// Perform forwarding session resumption.
@@ -913,6 +1266,14 @@ func (l *Liaison) resumeSessions(ctx context.Context, sidecarID string) error {
 // terminateSessions terminates Mutagen sessions for the project using the
 // specified sidecar container ID as the target identifier.
 func (l *Liaison) terminateSessions(ctx context.Context, sidecarID string) error {
+	// Apply the operation timeout and defer cancellation of the operation
+	// context.
+	ctx, cancel, err := l.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	// Create a Mutagen status updater, start the Mutagen status update, and
 	// defer its finalization.
 	status := newStatusUpdater(ctx, "Mutagen")
@@ -926,38 +1287,44 @@ func (l *Liaison) terminateSessions(ctx context.Context, sidecarID string) error
 		}
 	}()
 
-	// Connect to the Mutagen daemon and defer closure of the connection.
+	// Lock the project (to serialize operations with other Mutagen Compose
+	// invocations) and defer release of the lock.
+	ctx, unlock, err := l.lockProject(ctx, status, l.sidecarProjectName)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to lock project: %w", err)
+		return statusErr
+	}
+	defer unlock()
+
+	// Connect to the Mutagen daemon.
 	status.working("Connecting to Mutagen daemon")
-	daemonConnection, err := daemon.Connect(true, true)
+	daemonConnection, err := l.sharedDaemonConnection(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
 		return statusErr
 	}
-	defer daemonConnection.Close()
 
-	// Initiate message-only prompting via the status updater and defer its
-	// termination.
-	promptingCtx, promptingCancel := context.WithCancel(ctx)
-	prompter, promptingErrors, err := promptingsvc.Host(
-		promptingCtx, promptingsvc.NewPromptingClient(daemonConnection),
-		status, false,
-	)
-	defer func() {
-		promptingCancel()
-		<-promptingErrors
-	}()
+	// Relay prompting to the status updater and defer the relay's removal.
+	prompter, releasePrompter, err := l.sharedPrompter(status)
 	if err != nil {
 		statusErr = fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
 		return statusErr
 	}
+	defer releasePrompter()
 
 	// Create service clients.
 	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
 	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
 
 	// Create the session selection criteria.
-	projectSelection := &selection.Selection{
-		LabelSelector: fmt.Sprintf("%s == %s", sessionSidecarLabelKey, chopSidecarIdentifier(sidecarID)),
+	projectSelection := sidecarSessionSelection(sidecarID)
+	status.interruptionDetails = l.describeSessionStates(projectSelection)
+
+	// Verify that the selected sessions actually belong to the sidecar.
+	status.working("Verifying session ownership")
+	if err := ensureSidecarSessionOwnership(ctx, forwardingService, synchronizationService, projectSelection, sidecarID); err != nil {
+		statusErr = err
+		return statusErr
 	}
 
 	// Perform forwarding session termination.
@@ -974,6 +1341,197 @@ func (l *Liaison) terminateSessions(ctx context.Context, sidecarID string) error
 		return statusErr
 	}
 
+	// Stop the project-scoped Mutagen daemon if it's now idle.
+	status.working("Checking for idle project Mutagen daemon")
+	if _, err := l.stopIdleProjectDaemon(ctx, daemonConnection); err != nil {
+		statusErr = fmt.Errorf("unable to stop idle project Mutagen daemon: %w", err)
+		return statusErr
+	}
+
 	// Success.
 	return nil
 }
+
+// PruneSessions terminates Mutagen sessions associated with Mutagen Compose
+// sidecar containers that no longer exist. Only sessions targeting the Docker
+// daemon that Compose is currently targeting are considered. This method must
+// only be called after the Docker CLI and Docker flags have been registered.
+func (l *Liaison) PruneSessions(ctx context.Context) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		if pruned, err := l.pruneOrphanedSessions(ctx, false); err != nil {
+			return err
+		} else if pruned == 0 {
+			newStatusUpdater(ctx, "Mutagen").done("No orphaned sessions")
+		}
+		return nil
+	}, l.dockerCLI.Err(), "Pruning")
+}
+
+// pruneOrphanedSessions terminates Mutagen sessions whose associated Mutagen
+// Compose sidecar container no longer exists, returning the number of sessions
+// terminated. This can happen if a sidecar container is removed outside of
+// Mutagen Compose (e.g. via "docker rm -f"). Only sessions targeting the Docker
+// daemon that Compose is currently targeting are considered, since sidecar
+// containers on other daemons can't be checked. Status updates are only
+// emitted if orphaned sessions are found (or if an error occurs).
+//
+// If projectOnly is true, then only sessions labeled as belonging to the
+// current project are considered, and sessions whose names the project still
+// defines are left in place, since they may have been retained across a sidecar
+// recreation and session reconciliation will either replace or prune them.
+// Temporary sessions are never left in place. In this mode, it must only be
+// called after processProject.
+func (l *Liaison) pruneOrphanedSessions(ctx context.Context, projectOnly bool) (int, error) {
+	// Apply the operation timeout and defer cancellation of the operation
+	// context.
+	ctx, cancel, err := l.operationContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	// Create a Mutagen status updater and defer its finalization.
+	status := newStatusUpdater(ctx, "Mutagen")
+	var pruned int
+	var statusErr error
+	defer func() {
+		if statusErr != nil {
+			status.error(statusErr)
+		} else if pruned > 0 {
+			status.done(fmt.Sprintf("Pruned %d orphaned session(s)", pruned))
+		}
+	}()
+
+	// If only pruning the current project's sessions, then lock the project
+	// (to serialize operations with other Mutagen Compose invocations) and
+	// defer release of the lock. Pruning across all projects can't lock each
+	// affected project, but it only targets sessions whose sidecar containers
+	// no longer exist.
+	if projectOnly {
+		var unlock func()
+		ctx, unlock, err = l.lockProject(ctx, status, l.mutagenService.CustomLabels[api.ProjectLabel])
+		if err != nil {
+			statusErr = fmt.Errorf("unable to lock project: %w", err)
+			return 0, statusErr
+		}
+		defer unlock()
+	}
+
+	// Identify the sidecar containers that currently exist.
+	containers, err := l.dockerCLI.Client().ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", fmt.Sprintf("%s=%s", sidecarRoleLabelKey, sidecarRoleLabelValue)),
+		),
+		All: true,
+	})
+	if err != nil {
+		statusErr = fmt.Errorf("unable to query Mutagen sidecar containers: %w", err)
+		return 0, statusErr
+	}
+	sidecars := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		sidecars[chopSidecarIdentifier(container.ID)] = true
+	}
+
+	// Compute the transport reference used to identify sessions that target
+	// the current Docker daemon.
+	reference := sidecarTransportReference(l.dockerFlags, l.dockerCLI)
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(status)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+		return 0, statusErr
+	}
+
+	// Relay prompting to the status updater and defer the relay's removal.
+	prompter, releasePrompter, err := l.sharedPrompter(status)
+	if err != nil {
+		statusErr = fmt.Errorf("unable to initiate Mutagen prompting: %w", err)
+		return 0, statusErr
+	}
+	defer releasePrompter()
+
+	// Create service clients.
+	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
+	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
+
+	// Create selection criteria that match all Mutagen Compose sessions (or
+	// only those belonging to the current project).
+	composeSelection := &selection.Selection{LabelSelector: sessionSidecarLabelKey}
+	if projectOnly {
+		composeSelection.LabelSelector = fmt.Sprintf("%s, %s == %s, %s == %s", sessionSidecarLabelKey,
+			sessionProjectLabelKey, l.projectLabels[sessionProjectLabelKey],
+			sessionWorkingDirectoryLabelKey, l.projectLabels[sessionWorkingDirectoryLabelKey],
+		)
+	}
+
+	// Query Mutagen Compose forwarding sessions.
+	forwardingListResponse, err := forwardingService.List(ctx, &forwardingsvc.ListRequest{Selection: composeSelection})
+	if err != nil {
+		statusErr = fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		return 0, statusErr
+	} else if err = forwardingListResponse.EnsureValid(); err != nil {
+		statusErr = fmt.Errorf("invalid forwarding session listing response received: %w", err)
+		return 0, statusErr
+	}
+
+	// Query Mutagen Compose synchronization sessions.
+	synchronizationListResponse, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: composeSelection})
+	if err != nil {
+		statusErr = fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		return 0, statusErr
+	} else if err = synchronizationListResponse.EnsureValid(); err != nil {
+		statusErr = fmt.Errorf("invalid synchronization session listing response received: %w", err)
+		return 0, statusErr
+	}
+
+	// Identify orphaned forwarding sessions.
+	var forwardingPruneList []string
+	for _, state := range forwardingListResponse.SessionStates {
+		if !sharesSidecarTransport(state.Session.Destination, reference) {
+			continue
+		} else if _, defined := l.forwarding[state.Session.Name]; projectOnly && defined {
+			continue
+		} else if !sidecars[state.Session.Labels[sessionSidecarLabelKey]] {
+			forwardingPruneList = append(forwardingPruneList, state.Session.Identifier)
+		}
+	}
+
+	// Identify orphaned synchronization sessions.
+	var synchronizationPruneList []string
+	for _, state := range synchronizationListResponse.SessionStates {
+		if !(sharesSidecarTransport(state.Session.Alpha, reference) || sharesSidecarTransport(state.Session.Beta, reference)) {
+			continue
+		} else if _, defined := l.synchronization[state.Session.Name]; projectOnly && defined && !isTemporarySession(state.Session.Labels) {
+			continue
+		} else if !sidecars[state.Session.Labels[sessionSidecarLabelKey]] {
+			synchronizationPruneList = append(synchronizationPruneList, state.Session.Identifier)
+		}
+	}
+
+	// Terminate orphaned forwarding sessions.
+	if len(forwardingPruneList) > 0 {
+		status.working("Pruning orphaned Mutagen forwarding sessions")
+		pruneSelection := &selection.Selection{Specifications: forwardingPruneList}
+		if err := forwardingTerminateWithSelection(ctx, forwardingService, prompter, pruneSelection); err != nil {
+			statusErr = fmt.Errorf("unable to prune orphaned forwarding sessions: %w", err)
+			return 0, statusErr
+		}
+		pruned += len(forwardingPruneList)
+	}
+
+	// Terminate orphaned synchronization sessions.
+	if len(synchronizationPruneList) > 0 {
+		status.working("Pruning orphaned Mutagen synchronization sessions")
+		pruneSelection := &selection.Selection{Specifications: synchronizationPruneList}
+		if err := synchronizationTerminateWithSelection(ctx, synchronizationService, prompter, pruneSelection); err != nil {
+			statusErr = fmt.Errorf("unable to prune orphaned synchronization sessions: %w", err)
+			return 0, statusErr
+		}
+		pruned += len(synchronizationPruneList)
+	}
+
+	// Success.
+	return pruned, nil
+}
@@ -0,0 +1,170 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mutagen-io/mutagen/pkg/filesystem/locking"
+)
+
+const (
+	// projectLockTimeout is the maximum amount of time to wait for a project
+	// lock held by another Mutagen Compose invocation.
+	projectLockTimeout = 5 * time.Minute
+	// projectLockPollInterval is the interval at which acquisition of a project
+	// lock held by another Mutagen Compose invocation is retried.
+	projectLockPollInterval = 250 * time.Millisecond
+)
+
+// projectLock is an inter-process lock that serializes session operations for
+// a project across Mutagen Compose invocations.
+type projectLock struct {
+	// locker is the underlying file locker.
+	locker *locking.Locker
+	// owner is the operation holding the lock.
+	owner *projectLockOwner
+	// holds is the number of (nested) holds on the lock by its owner. File
+	// locks are owned by processes, so nested acquisition must be handled
+	// within the process.
+	holds int
+}
+
+// projectLockOwner identifies an operation that holds (or is acquiring) project
+// locks. It is recorded in the operation's context so that nested acquisitions
+// by the same operation can be distinguished from concurrent acquisitions by
+// unrelated operations within the process.
+type projectLockOwner struct{}
+
+// projectLockOwnerKey is the context key under which the project lock owner for
+// an operation is stored.
+type projectLockOwnerKey struct{}
+
+// projectLockPath computes the path of the lock file for the specified project
+// on the specified Docker endpoint, creating its parent directory if necessary.
+// Lock files are stored in the user's cache directory (rather than the Mutagen
+// data directory) since the latter can vary with project configuration.
+func projectLockPath(projectName, endpoint string) (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	directory := filepath.Join(base, "mutagen-compose", "locks")
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", fmt.Errorf("unable to create lock directory: %w", err)
+	}
+	return filepath.Join(directory, hashLabelValue(projectName+"\x00"+endpoint)+".lock"), nil
+}
+
+// lockProject acquires the lock for the specified project (on the Docker
+// endpoint currently being targeted), waiting for it to be released if it's
+// held by another Mutagen Compose invocation or by another operation within
+// this process. Waiting is reported via the status updater and is limited by
+// both the context and projectLockTimeout. Acquisition is reentrant for the
+// operation that holds the lock, which is identified by the returned context,
+// so nested operations must be performed using that context. It also returns a
+// function that releases the lock. This method must only be called after the
+// Docker CLI has been registered.
+func (l *Liaison) lockProject(ctx context.Context, status *statusUpdater, projectName string) (context.Context, func(), error) {
+	// Compute the lock path.
+	path, err := projectLockPath(projectName, l.dockerCLI.DockerEndpoint().Host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Identify the owning operation, recording it in the context if this is
+	// its first acquisition.
+	owner, _ := ctx.Value(projectLockOwnerKey{}).(*projectLockOwner)
+	if owner == nil {
+		owner = &projectLockOwner{}
+		ctx = context.WithValue(ctx, projectLockOwnerKey{}, owner)
+	}
+
+	// Create the release function.
+	release := func() {
+		l.projectLocksLock.Lock()
+		defer l.projectLocksLock.Unlock()
+		lock := l.projectLocks[path]
+		if lock.holds--; lock.holds == 0 {
+			lock.locker.Truncate(0)
+			lock.locker.Unlock()
+			lock.locker.Close()
+			delete(l.projectLocks, path)
+		}
+	}
+
+	// Attempt to acquire the lock, waiting if necessary. The lock registry
+	// isn't held while waiting.
+	deadline := time.NewTimer(projectLockTimeout)
+	defer deadline.Stop()
+	for waiting := false; ; {
+		if acquired, holder, err := l.tryLockProject(path, owner); err != nil {
+			return nil, nil, err
+		} else if acquired {
+			return ctx, release, nil
+		} else if !waiting {
+			status.working(fmt.Sprintf("Waiting for %s to release project lock", holder))
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("project lock acquisition cancelled: %w", ctx.Err())
+		case <-deadline.C:
+			return nil, nil, errors.New("timed out waiting for project lock to be released")
+		case <-time.After(projectLockPollInterval):
+		}
+	}
+}
+
+// tryLockProject attempts to acquire the project lock at the specified path on
+// behalf of the specified owner without waiting. If the lock is held by
+// another operation, then it returns a description of the holder.
+func (l *Liaison) tryLockProject(path string, owner *projectLockOwner) (bool, string, error) {
+	// Lock the lock registry and defer its release.
+	l.projectLocksLock.Lock()
+	defer l.projectLocksLock.Unlock()
+
+	// Handle locks already held within the process. Since file locks are owned
+	// by processes, at most one locker can exist for each path, so operations
+	// that don't own the lock have to wait for its release.
+	if lock, ok := l.projectLocks[path]; ok {
+		if lock.owner != owner {
+			return false, "another operation", nil
+		}
+		lock.holds++
+		return true, "", nil
+	}
+
+	// Create the locker and attempt to acquire the lock.
+	locker, err := locking.NewLocker(path, 0600)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to create project lock: %w", err)
+	} else if err := locker.Lock(false); err != nil {
+		locker.Close()
+		holder := "another Mutagen Compose invocation"
+		if contents, err := os.ReadFile(path); err == nil && len(contents) > 0 {
+			holder += " (process " + strings.TrimSpace(string(contents)) + ")"
+		}
+		return false, holder, nil
+	}
+
+	// Record the lock holder for the benefit of waiting invocations. This is
+	// purely informational, so failures are ignored.
+	if err := locker.Truncate(0); err == nil {
+		locker.Write([]byte(strconv.Itoa(os.Getpid())))
+	}
+
+	// Record the lock.
+	if l.projectLocks == nil {
+		l.projectLocks = make(map[string]*projectLock)
+	}
+	l.projectLocks[path] = &projectLock{locker: locker, owner: owner, holds: 1}
+
+	// Success.
+	return true, "", nil
+}
@@ -0,0 +1,251 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+const (
+	// metricsNamespace is the namespace used for Prometheus metrics.
+	metricsNamespace = "mutagen_compose"
+	// metricsPath is the HTTP path at which Prometheus metrics are served.
+	metricsPath = "/metrics"
+	// metricsQueryTimeout is the maximum amount of time that a metrics scrape
+	// will wait for the Mutagen daemon to respond.
+	metricsQueryTimeout = 10 * time.Second
+	// metricsShutdownTimeout is the maximum amount of time to wait for
+	// in-flight metrics requests to complete when shutting down.
+	metricsShutdownTimeout = 5 * time.Second
+)
+
+var (
+	// sessionLabels are the variable labels used for per-session metrics.
+	// Session names aren't necessarily unique (e.g. while a session is being
+	// replaced), so the session identifier is included to keep label sets
+	// distinct.
+	sessionLabels = []string{"project", "session", "kind", "identifier"}
+
+	// daemonUpDescription describes the daemon reachability metric.
+	daemonUpDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "daemon_up"),
+		"Whether or not the last Mutagen daemon query succeeded.",
+		[]string{"project"}, nil,
+	)
+	// sessionStatusDescription describes the session status metric.
+	sessionStatusDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "status"),
+		"Current session status (always 1, with the status in the status label).",
+		append(sessionLabels, "status"), nil,
+	)
+	// sessionPausedDescription describes the session paused metric.
+	sessionPausedDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "paused"),
+		"Whether or not the session is paused.",
+		sessionLabels, nil,
+	)
+	// sessionConflictsDescription describes the session conflicts metric.
+	sessionConflictsDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "conflicts"),
+		"Number of synchronization conflicts.",
+		sessionLabels, nil,
+	)
+	// sessionProblemsDescription describes the session problems metric.
+	sessionProblemsDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "problems"),
+		"Number of synchronization scan and transition problems.",
+		sessionLabels, nil,
+	)
+	// sessionSuccessfulCyclesDescription describes the session successful
+	// cycles metric.
+	sessionSuccessfulCyclesDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "successful_cycles_total"),
+		"Number of successful synchronization cycles.",
+		sessionLabels, nil,
+	)
+	// sessionStagingBytesDescription describes the session staging bytes
+	// metric.
+	sessionStagingBytesDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "staging_received_bytes"),
+		"Number of bytes received by the in-progress staging operation (0 if not staging).",
+		sessionLabels, nil,
+	)
+	// sessionEndpointConnectedDescription describes the session endpoint
+	// connectivity metric.
+	sessionEndpointConnectedDescription = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session", "endpoint_connected"),
+		"Whether or not the session endpoint is connected.",
+		append(sessionLabels, "endpoint"), nil,
+	)
+)
+
+// boolToGauge converts a boolean value to a gauge value.
+func boolToGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// sessionCollector is a Prometheus collector that queries the Mutagen daemon
+// for a project's sessions on each scrape.
+type sessionCollector struct {
+	// ctx is the context regulating daemon queries.
+	ctx context.Context
+	// projectName is the project name.
+	projectName string
+	// forwardingService is the forwarding service client.
+	forwardingService forwardingsvc.ForwardingClient
+	// synchronizationService is the synchronization service client.
+	synchronizationService synchronizationsvc.SynchronizationClient
+	// sessionSelection is the session selection criteria.
+	sessionSelection *selection.Selection
+	// errorLog is the writer to which query errors are reported.
+	errorLog io.Writer
+}
+
+// Describe implements prometheus.Collector.Describe.
+func (c *sessionCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- daemonUpDescription
+	descriptions <- sessionStatusDescription
+	descriptions <- sessionPausedDescription
+	descriptions <- sessionConflictsDescription
+	descriptions <- sessionProblemsDescription
+	descriptions <- sessionSuccessfulCyclesDescription
+	descriptions <- sessionStagingBytesDescription
+	descriptions <- sessionEndpointConnectedDescription
+}
+
+// Collect implements prometheus.Collector.Collect.
+func (c *sessionCollector) Collect(metrics chan<- prometheus.Metric) {
+	// Query the sessions. We select by project labels (rather than by sidecar
+	// container) so that metrics remain available across sidecar container
+	// recreation. If the query fails, then we report the daemon as down rather
+	// than failing the scrape entirely.
+	ctx, cancel := context.WithTimeout(c.ctx, metricsQueryTimeout)
+	defer cancel()
+	summaries, err := querySessionSummaries(ctx,
+		c.forwardingService, c.synchronizationService,
+		c.sessionSelection, "",
+	)
+	if err != nil {
+		fmt.Fprintln(c.errorLog, "Unable to query Mutagen sessions:", err)
+	}
+	metrics <- prometheus.MustNewConstMetric(daemonUpDescription,
+		prometheus.GaugeValue, boolToGauge(err == nil), c.projectName,
+	)
+
+	// Report per-session metrics.
+	for _, summary := range summaries {
+		labels := []string{c.projectName, summary.Name, summary.Kind, summary.Identifier}
+		metrics <- prometheus.MustNewConstMetric(sessionStatusDescription,
+			prometheus.GaugeValue, 1, append(labels, summary.Status)...,
+		)
+		metrics <- prometheus.MustNewConstMetric(sessionPausedDescription,
+			prometheus.GaugeValue, boolToGauge(summary.Status == pausedStatusDescription), labels...,
+		)
+		metrics <- prometheus.MustNewConstMetric(sessionConflictsDescription,
+			prometheus.GaugeValue, float64(summary.Conflicts), labels...,
+		)
+		metrics <- prometheus.MustNewConstMetric(sessionProblemsDescription,
+			prometheus.GaugeValue, float64(summary.Problems), labels...,
+		)
+		metrics <- prometheus.MustNewConstMetric(sessionSuccessfulCyclesDescription,
+			prometheus.CounterValue, float64(summary.SuccessfulCycles), labels...,
+		)
+		var stagingBytes uint64
+		if summary.Staging != nil {
+			stagingBytes = summary.Staging.ReceivedBytes
+		}
+		metrics <- prometheus.MustNewConstMetric(sessionStagingBytesDescription,
+			prometheus.GaugeValue, float64(stagingBytes), labels...,
+		)
+		for e, endpoint := range sessionEndpointNames(summary.Kind) {
+			if e < len(summary.Connected) {
+				metrics <- prometheus.MustNewConstMetric(sessionEndpointConnectedDescription,
+					prometheus.GaugeValue, boolToGauge(summary.Connected[e]), append(labels, endpoint)...,
+				)
+			}
+		}
+	}
+}
+
+// ServeMetrics serves Prometheus metrics describing the specified project's
+// Mutagen sessions on the specified listening address. The Mutagen daemon is
+// queried on each scrape. It runs until the context is cancelled, at which
+// point it returns nil. Informational output and query errors are written to
+// out. This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) ServeMetrics(ctx context.Context, projectName, address string, out io.Writer) error {
+	// Ensure that the project has a sidecar container. We don't require that
+	// it continue to exist, but serving metrics for a project that doesn't use
+	// Mutagen is almost certainly a mistake.
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return err
+	} else if sidecar == nil {
+		return errNoSidecar
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(nil)
+	if err != nil {
+		return fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Create a registry with a session collector.
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(&sessionCollector{
+		ctx:                    ctx,
+		projectName:            projectName,
+		forwardingService:      forwardingsvc.NewForwardingClient(daemonConnection),
+		synchronizationService: synchronizationsvc.NewSynchronizationClient(daemonConnection),
+		sessionSelection:       sidecarProjectSessionSelection(sidecar.Labels),
+		errorLog:               out,
+	}); err != nil {
+		return fmt.Errorf("unable to register metrics collector: %w", err)
+	}
+
+	// Start listening.
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("unable to listen for metrics requests: %w", err)
+	}
+
+	// Create the server.
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux}
+
+	// Serve metrics in a background Goroutine.
+	serveErrors := make(chan error, 1)
+	go func() {
+		serveErrors <- server.Serve(listener)
+	}()
+	fmt.Fprintf(out, "Serving metrics at http://%s%s\n", listener.Addr(), metricsPath)
+
+	// Wait for cancellation or server failure.
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+		return nil
+	case err := <-serveErrors:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+}
@@ -0,0 +1,438 @@
+package mutagen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+// MonitorOptions are the options for monitoring Mutagen sessions.
+type MonitorOptions struct {
+	// Forwarding indicates whether or not to monitor forwarding sessions.
+	Forwarding bool
+	// Synchronization indicates whether or not to monitor synchronization
+	// sessions.
+	Synchronization bool
+	// Names restricts monitoring to sessions with the specified names. If no
+	// names are specified, then all sessions are monitored.
+	Names []string
+	// JSON indicates whether or not to emit newline-delimited JSON events (one
+	// per session state change) instead of a human-readable display.
+	JSON bool
+	// ANSI indicates whether or not ANSI control sequences can be used to
+	// redraw the human-readable display in place. If false, a line is printed
+	// for each session state change.
+	ANSI bool
+}
+
+// MonitorEvent is the JSON representation of a session state change emitted
+// during monitoring.
+type MonitorEvent struct {
+	// Timestamp is the time at which the state change was observed.
+	Timestamp time.Time `json:"timestamp"`
+	// SessionSummary is the new session state.
+	SessionSummary
+}
+
+// monitorUpdate is an update from a session polling loop.
+type monitorUpdate struct {
+	// kind is the session kind for the update.
+	kind string
+	// summaries are the current session states for the session kind.
+	summaries []SessionSummary
+	// err is any error that terminated polling.
+	err error
+}
+
+// formatStagingProgress formats staging progress for human-readable display.
+func formatStagingProgress(staging *StagingSummary) string {
+	return fmt.Sprintf("%d/%d files, %s",
+		staging.ReceivedFiles, staging.ExpectedFiles,
+		units.HumanSize(float64(staging.ReceivedBytes)),
+	)
+}
+
+// formatMonitorStatus formats a session status (including any staging
+// progress) for human-readable display.
+func formatMonitorStatus(summary SessionSummary) string {
+	if summary.Staging != nil {
+		return fmt.Sprintf("%s (%s)", summary.Status, formatStagingProgress(summary.Staging))
+	}
+	return summary.Status
+}
+
+// formatConnectedEndpoints formats the number of connected endpoints for a
+// session for human-readable display.
+func formatConnectedEndpoints(summary SessionSummary) string {
+	var connected int
+	for _, c := range summary.Connected {
+		if c {
+			connected++
+		}
+	}
+	return fmt.Sprintf("%d/%d", connected, len(summary.Connected))
+}
+
+// formatMonitorLine formats a single-line human-readable description of a
+// session's state.
+func formatMonitorLine(summary SessionSummary) string {
+	line := fmt.Sprintf("%s (%s): %s | connected: %s | conflicts: %d | problems: %d",
+		summary.Name, summary.Kind, formatMonitorStatus(summary),
+		formatConnectedEndpoints(summary), summary.Conflicts, summary.Problems,
+	)
+	if summary.LastError != "" {
+		line += " | error: " + summary.LastError
+	}
+	return line
+}
+
+// filterSessionSummaries filters session summaries to those with the specified
+// names. If names is nil, then no filtering is performed.
+func filterSessionSummaries(summaries []SessionSummary, names map[string]bool) []SessionSummary {
+	if names == nil {
+		return summaries
+	}
+	var filtered []SessionSummary
+	for _, summary := range summaries {
+		if names[summary.Name] {
+			filtered = append(filtered, summary)
+		}
+	}
+	return filtered
+}
+
+// pollForwardingSessions polls for forwarding session state changes using the
+// daemon's state index, sending updates until the context is cancelled or an
+// error occurs. If sidecarID is non-empty, then session ownership is verified.
+// If stateIndex is non-zero, then polling starts by waiting for changes beyond
+// that state index, otherwise the current state is sent immediately.
+func pollForwardingSessions(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	sessionSelection *selection.Selection,
+	sidecarID string,
+	stateIndex uint64,
+	updates chan<- monitorUpdate,
+) {
+	previousStateIndex := stateIndex
+	for {
+		update := monitorUpdate{kind: SessionKindForwarding}
+		response, err := forwardingService.List(ctx, &forwardingsvc.ListRequest{
+			Selection:          sessionSelection,
+			PreviousStateIndex: previousStateIndex,
+		})
+		if err != nil {
+			update.err = fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		} else if err = response.EnsureValid(); err != nil {
+			update.err = fmt.Errorf("invalid forwarding session listing response received: %w", err)
+		} else if sidecarID != "" {
+			update.err = checkSidecarSessionOwnership(response.SessionStates, nil, sidecarID)
+		}
+		if update.err == nil {
+			previousStateIndex = response.StateIndex
+			for _, state := range response.SessionStates {
+				update.summaries = append(update.summaries, newForwardingSessionSummary(state))
+			}
+		}
+		select {
+		case updates <- update:
+		case <-ctx.Done():
+			return
+		}
+		if update.err != nil {
+			return
+		}
+	}
+}
+
+// pollSynchronizationSessions polls for synchronization session state changes
+// using the daemon's state index, sending updates until the context is
+// cancelled or an error occurs. If sidecarID is non-empty, then session
+// ownership is verified. The state index is handled as for
+// pollForwardingSessions.
+func pollSynchronizationSessions(
+	ctx context.Context,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	sidecarID string,
+	stateIndex uint64,
+	updates chan<- monitorUpdate,
+) {
+	previousStateIndex := stateIndex
+	for {
+		update := monitorUpdate{kind: SessionKindSynchronization}
+		response, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{
+			Selection:          sessionSelection,
+			PreviousStateIndex: previousStateIndex,
+		})
+		if err != nil {
+			update.err = fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+		} else if err = response.EnsureValid(); err != nil {
+			update.err = fmt.Errorf("invalid synchronization session listing response received: %w", err)
+		} else if sidecarID != "" {
+			update.err = checkSidecarSessionOwnership(nil, response.SessionStates, sidecarID)
+		}
+		if update.err == nil {
+			previousStateIndex = response.StateIndex
+			for _, state := range response.SessionStates {
+				update.summaries = append(update.summaries, newSynchronizationSessionSummary(state))
+			}
+		}
+		select {
+		case updates <- update:
+		case <-ctx.Done():
+			return
+		}
+		if update.err != nil {
+			return
+		}
+	}
+}
+
+// sessionMonitor renders session states during monitoring.
+type sessionMonitor struct {
+	// out is the output destination.
+	out io.Writer
+	// options are the monitoring options.
+	options MonitorOptions
+	// current maps session kinds to their current session states.
+	current map[string][]SessionSummary
+	// previous maps session identifiers to the last rendered representation
+	// of their state. It's only used for line-based and JSON output.
+	previous map[string]string
+	// previousSummaries maps session identifiers to their last rendered
+	// state. It's only used for line-based and JSON output.
+	previousSummaries map[string]SessionSummary
+	// drawnLines is the number of lines drawn in the previous in-place
+	// display. It's only used for ANSI output.
+	drawnLines int
+}
+
+// sessions returns the current session states in display order.
+func (m *sessionMonitor) sessions() []SessionSummary {
+	var sessions []SessionSummary
+	sessions = append(sessions, m.current[SessionKindSynchronization]...)
+	sessions = append(sessions, m.current[SessionKindForwarding]...)
+	return sessions
+}
+
+// emit renders a single session state change for line-based or JSON output.
+func (m *sessionMonitor) emit(summary SessionSummary) error {
+	if m.options.JSON {
+		return json.NewEncoder(m.out).Encode(MonitorEvent{time.Now(), summary})
+	}
+	_, err := fmt.Fprintln(m.out, formatMonitorLine(summary))
+	return err
+}
+
+// renderChanges renders session state changes for line-based or JSON output.
+func (m *sessionMonitor) renderChanges() error {
+	// Render sessions whose state has changed.
+	present := make(map[string]bool)
+	for _, summary := range m.sessions() {
+		present[summary.Identifier] = true
+		representation := formatMonitorLine(summary)
+		if m.previous[summary.Identifier] == representation {
+			continue
+		}
+		if err := m.emit(summary); err != nil {
+			return err
+		}
+		m.previous[summary.Identifier] = representation
+		m.previousSummaries[summary.Identifier] = summary
+	}
+
+	// Render sessions that have disappeared.
+	for identifier, summary := range m.previousSummaries {
+		if present[identifier] {
+			continue
+		}
+		summary.Status = "Terminated"
+		summary.Connected = make([]bool, len(summary.Connected))
+		summary.Staging = nil
+		if err := m.emit(summary); err != nil {
+			return err
+		}
+		delete(m.previous, identifier)
+		delete(m.previousSummaries, identifier)
+	}
+
+	// Success.
+	return nil
+}
+
+// renderDisplay redraws the in-place session display for ANSI output.
+func (m *sessionMonitor) renderDisplay() error {
+	// Render the display into a buffer.
+	buffer := &bytes.Buffer{}
+	sessions := m.sessions()
+	if len(sessions) == 0 {
+		fmt.Fprintln(buffer, "No sessions found")
+	} else {
+		writer := tabwriter.NewWriter(buffer, 10, 1, 3, ' ', 0)
+		fmt.Fprintln(writer, "SESSION\tKIND\tSTATUS\tCONNECTED\tCONFLICTS\tPROBLEMS")
+		for _, summary := range sessions {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				summary.Name, summary.Kind, formatMonitorStatus(summary),
+				formatConnectedEndpoints(summary), summary.Conflicts, summary.Problems,
+			)
+		}
+		writer.Flush()
+		for _, summary := range sessions {
+			if summary.LastError != "" {
+				fmt.Fprintf(buffer, "%s: %s\n", summary.Name, summary.LastError)
+			}
+		}
+	}
+
+	// Truncate lines to the terminal width (if known) so that they don't wrap,
+	// since wrapped lines would throw off the line count used for erasure.
+	lines := strings.SplitAfter(buffer.String(), "\n")
+	lines = lines[:len(lines)-1]
+	if width := terminalWidth(m.out); width > 0 {
+		for l, line := range lines {
+			if runes := []rune(strings.TrimSuffix(line, "\n")); len(runes) > width {
+				lines[l] = string(runes[:width]) + "\n"
+			}
+		}
+	}
+
+	// Erase the previous display and draw the new one.
+	if m.drawnLines > 0 {
+		fmt.Fprintf(m.out, "\x1b[%dA\x1b[J", m.drawnLines)
+	}
+	m.drawnLines = len(lines)
+	_, err := io.WriteString(m.out, strings.Join(lines, ""))
+	return err
+}
+
+// terminalWidth returns the width of the terminal underlying the specified
+// writer, or 0 if the writer isn't a terminal or its width can't be determined.
+func terminalWidth(out io.Writer) int {
+	if sized, ok := out.(interface{ GetTtySize() (uint, uint) }); ok {
+		_, width := sized.GetTtySize()
+		return int(width)
+	}
+	return 0
+}
+
+// MonitorSessions displays the states of the specified project's Mutagen
+// sessions as they change, using the daemon's state index to wait for changes.
+// Sessions are selected by project (rather than by sidecar container), so
+// monitoring continues across sidecar container recreation. It runs until the
+// context is cancelled, at which point it returns nil. This method must only be
+// called after the Docker CLI has been registered.
+func (l *Liaison) MonitorSessions(ctx context.Context, projectName string, options MonitorOptions, out io.Writer) error {
+	// Identify the sidecar container.
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return err
+	} else if sidecar == nil {
+		return errNoSidecar
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(nil)
+	if err != nil {
+		return fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Create service clients.
+	forwardingService := forwardingsvc.NewForwardingClient(daemonConnection)
+	synchronizationService := synchronizationsvc.NewSynchronizationClient(daemonConnection)
+
+	// Create the session selection criteria.
+	projectSelection := sidecarProjectSessionSelection(sidecar.Labels)
+
+	// If names have been specified, then ensure that they exist. We filter by
+	// name (rather than selecting by identifier) so that monitoring continues
+	// to work if sessions are recreated.
+	var names map[string]bool
+	if len(options.Names) > 0 {
+		known := make(map[string]bool)
+		if options.Forwarding {
+			response, err := forwardingService.List(ctx, &forwardingsvc.ListRequest{Selection: projectSelection})
+			if err != nil {
+				return fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+			} else if err = response.EnsureValid(); err != nil {
+				return fmt.Errorf("invalid forwarding session listing response received: %w", err)
+			}
+			for _, state := range response.SessionStates {
+				known[state.Session.Name] = true
+			}
+		}
+		if options.Synchronization {
+			response, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: projectSelection})
+			if err != nil {
+				return fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+			} else if err = response.EnsureValid(); err != nil {
+				return fmt.Errorf("invalid synchronization session listing response received: %w", err)
+			}
+			for _, state := range response.SessionStates {
+				known[state.Session.Name] = true
+			}
+		}
+		names = make(map[string]bool, len(options.Names))
+		for _, name := range options.Names {
+			if !known[name] {
+				return fmt.Errorf("no session named \"%s\" exists for project", name)
+			}
+			names[name] = true
+		}
+	}
+
+	// Start polling for session state changes and defer polling termination.
+	pollingCtx, pollingCancel := context.WithCancel(ctx)
+	defer pollingCancel()
+	updates := make(chan monitorUpdate)
+	if options.Forwarding {
+		go pollForwardingSessions(pollingCtx, forwardingService, projectSelection, "", 0, updates)
+	}
+	if options.Synchronization {
+		go pollSynchronizationSessions(pollingCtx, synchronizationService, projectSelection, "", 0, updates)
+	}
+
+	// Render updates until cancelled.
+	monitor := &sessionMonitor{
+		out:               out,
+		options:           options,
+		current:           make(map[string][]SessionSummary),
+		previous:          make(map[string]string),
+		previousSummaries: make(map[string]SessionSummary),
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update := <-updates:
+			if update.err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return update.err
+			}
+			monitor.current[update.kind] = filterSessionSummaries(update.summaries, names)
+			var err error
+			if options.ANSI && !options.JSON {
+				err = monitor.renderDisplay()
+			} else {
+				err = monitor.renderChanges()
+			}
+			if err != nil {
+				return fmt.Errorf("unable to render session states: %w", err)
+			}
+		}
+	}
+}
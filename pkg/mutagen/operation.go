@@ -0,0 +1,104 @@
+package mutagen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+const (
+	// OperationTimeoutEnvironmentVariable is the environment variable that can
+	// be used to specify the maximum duration of individual Mutagen operations
+	// (e.g. session reconciliation or flushing), using Go duration syntax. A
+	// value of "0" disables the timeout. It takes precedence over any timeout
+	// specified in the x-mutagen section of the project. By default, operations
+	// don't time out, since they may include initial synchronization of
+	// arbitrarily large content.
+	OperationTimeoutEnvironmentVariable = "MUTAGEN_COMPOSE_OPERATION_TIMEOUT"
+	// interruptionQueryTimeout is the maximum amount of time to spend querying
+	// session states when reporting an interrupted operation.
+	interruptionQueryTimeout = 5 * time.Second
+)
+
+// parseOperationTimeout parses an operation timeout specification.
+func parseOperationTimeout(specification string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(specification)
+	if err != nil {
+		return 0, fmt.Errorf("invalid operation timeout specification: %w", err)
+	} else if timeout < 0 {
+		return 0, fmt.Errorf("negative operation timeout specification: %s", specification)
+	}
+	return timeout, nil
+}
+
+// operationTimeout returns the maximum duration of individual Mutagen
+// operations, or 0 if operations shouldn't time out.
+func (l *Liaison) operationTimeout() (time.Duration, error) {
+	if specification := os.Getenv(OperationTimeoutEnvironmentVariable); specification != "" {
+		timeout, err := parseOperationTimeout(specification)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s value: %w", OperationTimeoutEnvironmentVariable, err)
+		}
+		return timeout, nil
+	}
+	return l.projectOperationTimeout, nil
+}
+
+// operationContext derives a context for an individual Mutagen operation that
+// is subject to the operation timeout (if any). The caller must invoke the
+// returned cancellation function when the operation is complete.
+func (l *Liaison) operationContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	timeout, err := l.operationTimeout()
+	if err != nil {
+		return nil, nil, err
+	} else if timeout == 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// describeSessionStates returns a function that describes the states of the
+// sessions matching the specified selection, for use in reporting interrupted
+// operations. Since the operation's context will have been cancelled, the
+// query is performed using an independent (short) timeout. If the shared
+// daemon connection hasn't been established, then no description is provided.
+func (l *Liaison) describeSessionStates(sessionSelection *selection.Selection) func() string {
+	return func() string {
+		// Grab the shared daemon connection, if any.
+		l.daemon.lock.Lock()
+		daemonConnection := l.daemon.connection
+		l.daemon.lock.Unlock()
+		if daemonConnection == nil {
+			return ""
+		}
+
+		// Query the sessions.
+		ctx, cancel := context.WithTimeout(context.Background(), interruptionQueryTimeout)
+		defer cancel()
+		summaries, err := querySessionSummaries(ctx,
+			forwardingsvc.NewForwardingClient(daemonConnection),
+			synchronizationsvc.NewSynchronizationClient(daemonConnection),
+			sessionSelection, "",
+		)
+		if err != nil {
+			return "session states unknown"
+		} else if len(summaries) == 0 {
+			return "no sessions remain"
+		}
+
+		// Format the states.
+		states := make([]string, len(summaries))
+		for s, summary := range summaries {
+			states[s] = fmt.Sprintf("%s: %s", summary.Name, summary.Status)
+		}
+		return "sessions left " + strings.Join(states, ", ")
+	}
+}
@@ -3,40 +3,146 @@ package mutagen
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 
 	"github.com/docker/compose/v2/pkg/progress"
+
+	"github.com/mutagen-io/mutagen/pkg/forwarding"
+	"github.com/mutagen-io/mutagen/pkg/prompting"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
 )
 
+// eventGate regulates the emission of Compose progress events so that they can
+// be suppressed while an interactive prompt is displayed. Otherwise, events
+// emitted concurrently with prompting (e.g. by session progress tracking) would
+// be rendered over the prompt.
+type eventGate struct {
+	// lock serializes event emission and access to the remaining fields.
+	lock sync.Mutex
+	// prompting indicates whether or not a prompt is being displayed.
+	prompting bool
+}
+
+// terminalEvents is the event gate shared by all status updaters and session
+// progress trackers. The terminal is a process-wide resource, so there's only
+// one gate.
+var terminalEvents eventGate
+
+// emit emits an event using the specified writer, unless a prompt is being
+// displayed. It returns whether or not the event was emitted.
+func (g *eventGate) emit(writer progress.Writer, event progress.Event) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.prompting {
+		return false
+	}
+	writer.Event(event)
+	return true
+}
+
+// suppress suppresses event emission until the returned function is invoked.
+// Any event being emitted when suppress is invoked is allowed to complete.
+func (g *eventGate) suppress() func() {
+	g.lock.Lock()
+	g.prompting = true
+	g.lock.Unlock()
+	return func() {
+		g.lock.Lock()
+		g.prompting = false
+		g.lock.Unlock()
+	}
+}
+
 // statusUpdater provides an adapter for feeding Mutagen-related events to the
 // Compose progress writer. It also implements the
 // github.com/mutagen-io/mutagen/pkg/prompting.Prompter interface to provide
-// message-only prompting.
+// message-only prompting (or interactive prompting, if enabled).
 type statusUpdater struct {
+	// ctx is the context regulating the operation being reported.
+	ctx context.Context
 	// writer is the underlying Compose progress writer.
 	writer progress.Writer
 	// eventID is the identifier to use for events.
 	eventID string
+	// step is the description of the most recent working event.
+	step string
+	// interruptionDetails, if non-nil, is invoked to describe the state in
+	// which the operation left its sessions if the operation is interrupted.
+	interruptionDetails func() string
+	// interactive indicates whether or not interactive prompting is supported.
+	// It is set when the updater is registered as a prompting target.
+	interactive bool
+	// terminal is the terminal to which interactive prompts are written. It is
+	// set when the updater is registered as a prompting target.
+	terminal io.Writer
 }
 
 // newStatusUpdater extracts the Compose progress writer from the specified
-// context and constructs a new statusUpdater.
+// context and constructs a new statusUpdater. The context should be the one
+// regulating the operation being reported so that interruptions can be
+// identified.
 func newStatusUpdater(ctx context.Context, eventID string) *statusUpdater {
-	return &statusUpdater{writer: progress.ContextWriter(ctx), eventID: eventID}
+	return &statusUpdater{ctx: ctx, writer: progress.ContextWriter(ctx), eventID: eventID}
+}
+
+// reportStatus emits status events for operations that are performed outside
+// of the underlying Compose service (which manages its own progress output)
+// using a transient Compose progress writer targeting the specified output.
+func reportStatus(ctx context.Context, out io.Writer, eventID string, report func(*statusUpdater)) {
+	progress.Run(ctx, func(ctx context.Context) error {
+		report(newStatusUpdater(ctx, eventID))
+		return nil
+	}, out)
 }
 
 // working registers a normal working event.
 func (u *statusUpdater) working(description string) {
-	u.writer.Event(progress.NewEvent(u.eventID, progress.Working, description))
+	u.step = description
+	terminalEvents.emit(u.writer, progress.NewEvent(u.eventID, progress.Working, description))
 }
 
-// error registers an error event.
+// warning registers a warning event.
+func (u *statusUpdater) warning(description string) {
+	terminalEvents.emit(u.writer, progress.NewEvent(u.eventID, progress.Warning, description))
+}
+
+// error registers an error event. If the operation's context has been
+// cancelled or has exceeded its deadline, then the error is instead reported as
+// an interruption of the most recent step, along with any interruption details.
 func (u *statusUpdater) error(err error) {
-	u.writer.Event(progress.NewEvent(u.eventID, progress.Error, "Error: "+err.Error()))
+	// Handle non-interruption errors.
+	cause := u.ctx.Err()
+	if cause == nil {
+		terminalEvents.emit(u.writer, progress.NewEvent(u.eventID, progress.Error, "Error: "+err.Error()))
+		return
+	}
+
+	// Describe the interruption.
+	reason := "Interrupted"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "Timed out"
+	}
+	description := reason
+	if u.step != "" {
+		description = fmt.Sprintf("%s while %s", reason, strings.ToLower(u.step[:1])+u.step[1:])
+	}
+	if u.interruptionDetails != nil {
+		if details := u.interruptionDetails(); details != "" {
+			description += " (" + details + ")"
+		}
+	}
+	terminalEvents.emit(u.writer, progress.NewEvent(u.eventID, progress.Error, description))
 }
 
 // done registers a done event.
 func (u *statusUpdater) done(description string) {
-	u.writer.Event(progress.NewEvent(u.eventID, progress.Done, description))
+	terminalEvents.emit(u.writer, progress.NewEvent(u.eventID, progress.Done, description))
 }
 
 // Message implements
@@ -47,7 +153,264 @@ func (u *statusUpdater) Message(message string) error {
 }
 
 // Prompt implements
-// github.com/mutagen-io/mutagen/pkg/prompting.Prompter.Prompt.
-func (u *statusUpdater) Prompt(_ string) (string, error) {
-	return "", errors.New("prompting not supported")
+// github.com/mutagen-io/mutagen/pkg/prompting.Prompter.Prompt. If interactive
+// prompting is supported, then progress events are suppressed while the prompt
+// is performed on the terminal. The prompt is erased once a response has been
+// received so that the progress display can continue in place.
+func (u *statusUpdater) Prompt(prompt string) (string, error) {
+	// If interactive prompting isn't supported, then we can't prompt.
+	if !u.interactive {
+		return "", errors.New("prompting not supported")
+	}
+
+	// Suppress progress events (from this and any other operation) and defer
+	// their resumption. The progress writer itself is left running, since its
+	// lifecycle is owned by Compose.
+	defer terminalEvents.suppress()()
+
+	// Perform command line prompting and then erase the prompt, which will
+	// occupy one line more than the number of line breaks that it contains
+	// (since the response is terminated with a line break). Mutagen's command
+	// line prompting writes the prompt to standard output, which is the
+	// terminal recorded for prompting, so the prompt is erased there (rather
+	// than on the progress output stream).
+	response, err := prompting.PromptCommandLine(prompt)
+	fmt.Fprintf(u.terminal, "\x1b[%dA\x1b[J", strings.Count(prompt, "\n")+1)
+	return response, err
+}
+
+// sessionEventID returns the progress event identifier for a session (e.g.
+// "Mutagen sync code" or "Mutagen forward db").
+func sessionEventID(summary SessionSummary) string {
+	kind := "sync"
+	if summary.Kind == SessionKindForwarding {
+		kind = "forward"
+	}
+	return fmt.Sprintf("Mutagen %s %s", kind, summary.Name)
+}
+
+// sessionSettled determines whether or not a session has reached a steady
+// state (i.e. it's paused, watching for changes, or forwarding connections).
+func sessionSettled(summary SessionSummary) bool {
+	switch summary.Status {
+	case pausedStatusDescription,
+		synchronization.Status_Watching.Description(),
+		forwarding.Status_ForwardingConnections.Description():
+		return true
+	default:
+		return false
+	}
+}
+
+// sessionProgress reports the live states of individual sessions as separate
+// Compose progress events.
+type sessionProgress struct {
+	// writer is the underlying Compose progress writer.
+	writer progress.Writer
+	// names are the names of the sessions to report, or nil to report all
+	// sessions.
+	names map[string]bool
+	// cancel cancels session polling.
+	cancel context.CancelFunc
+	// finished is closed when the tracking loop has exited.
+	finished chan struct{}
+	// order is the order in which sessions were first observed.
+	order []string
+	// last maps session identifiers to their last observed states. Sessions
+	// that are no longer present are mapped to nil.
+	last map[string]*SessionSummary
+	// reported maps session identifiers to their last reported status.
+	reported map[string]string
+	// staging maps session identifiers to the latest observed progress of any
+	// ongoing staging operation.
+	staging map[string]*StagingSummary
+	// staged maps session identifiers to the total staging progress observed
+	// for completed staging operations.
+	staged map[string]*StagingSummary
+	// stopped indicates whether or not tracking has been stopped.
+	stopped bool
+}
+
+// trackSessionProgress starts reporting the states of the sessions matching the
+// specified selection (and names, if non-nil) as individual progress events
+// (using the progress writer from the specified context). Either service client
+// may be nil, in which case sessions of that kind aren't tracked. Sessions that
+// disappear while being tracked are reported as terminated, so the selection
+// should be label-based rather than identifier-based. It waits for the initial session states
+// before returning so that every session is reported at least once. Tracking is
+// best-effort, so polling failures simply halt updates. The caller must invoke
+// stop once the operation being tracked is complete.
+func trackSessionProgress(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	names map[string]bool,
+) *sessionProgress {
+	// Create the tracker.
+	pollingCtx, cancel := context.WithCancel(ctx)
+	tracker := &sessionProgress{
+		writer:   progress.ContextWriter(ctx),
+		names:    names,
+		cancel:   cancel,
+		finished: make(chan struct{}),
+		last:     make(map[string]*SessionSummary),
+		reported: make(map[string]string),
+		staging:  make(map[string]*StagingSummary),
+		staged:   make(map[string]*StagingSummary),
+	}
+
+	// Start polling.
+	updates := make(chan monitorUpdate)
+	var pending int
+	if forwardingService != nil {
+		go pollForwardingSessions(pollingCtx, forwardingService, sessionSelection, "", 0, updates)
+		pending++
+	}
+	if synchronizationService != nil {
+		go pollSynchronizationSessions(pollingCtx, synchronizationService, sessionSelection, "", 0, updates)
+		pending++
+	}
+
+	// Process the initial states synchronously.
+	for ; pending > 0; pending-- {
+		select {
+		case update := <-updates:
+			tracker.update(update)
+		case <-pollingCtx.Done():
+			pending = 0
+		}
+	}
+
+	// Process subsequent states asynchronously.
+	go func() {
+		defer close(tracker.finished)
+		for {
+			select {
+			case update := <-updates:
+				tracker.update(update)
+			case <-pollingCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Done.
+	return tracker
+}
+
+// update processes a polling update, reporting any session state changes.
+func (p *sessionProgress) update(update monitorUpdate) {
+	// Ignore polling failures.
+	if update.err != nil {
+		return
+	}
+
+	// Record and report current states.
+	summaries := filterSessionSummaries(update.summaries, p.names)
+	present := make(map[string]bool, len(summaries))
+	for s := range summaries {
+		summary := summaries[s]
+		present[summary.Identifier] = true
+		if _, ok := p.last[summary.Identifier]; !ok {
+			p.order = append(p.order, summary.Identifier)
+		}
+		p.last[summary.Identifier] = &summary
+		p.recordStaging(summary.Identifier, summary.Staging)
+		if status := formatMonitorStatus(summary); p.reported[summary.Identifier] != status {
+			if terminalEvents.emit(p.writer, progress.NewEvent(sessionEventID(summary), progress.Working, status)) {
+				p.reported[summary.Identifier] = status
+			}
+		}
+	}
+
+	// Report sessions of this kind that have disappeared.
+	for identifier, summary := range p.last {
+		if summary == nil || summary.Kind != update.kind || present[identifier] {
+			continue
+		}
+		terminalEvents.emit(p.writer, progress.NewEvent(sessionEventID(*summary), progress.Done, "Terminated"))
+		p.last[identifier] = nil
+		p.recordStaging(identifier, nil)
+	}
+}
+
+// stop halts tracking and finalizes the event for each tracked session. Sessions
+// that have reached a steady state are reported as done, sessions with errors
+// are reported as errors, and all others are reported as warnings with their
+// last known status.
+func (p *sessionProgress) stop() {
+	// Halt tracking, unless it's already been halted.
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	p.cancel()
+	<-p.finished
+
+	// Record any ongoing staging operations as complete.
+	for identifier := range p.staging {
+		p.recordStaging(identifier, nil)
+	}
+
+	// Finalize session events.
+	for _, identifier := range p.order {
+		summary := p.last[identifier]
+		if summary == nil {
+			continue
+		}
+		eventID := sessionEventID(*summary)
+		if summary.LastError != "" {
+			terminalEvents.emit(p.writer, progress.NewEvent(eventID, progress.Error, "Error: "+summary.LastError))
+		} else if sessionSettled(*summary) {
+			terminalEvents.emit(p.writer, progress.NewEvent(eventID, progress.Done, summary.Status))
+		} else {
+			terminalEvents.emit(p.writer, progress.NewEvent(eventID, progress.Warning, formatMonitorStatus(*summary)))
+		}
+	}
+}
+
+// recordStaging records the latest staging progress for a session (which may be
+// nil if the session isn't staging). Staging progress is only observed when the
+// session state changes, so recorded totals are a lower bound.
+func (p *sessionProgress) recordStaging(identifier string, staging *StagingSummary) {
+	// If the current staging operation is continuing, then simply update its
+	// progress.
+	current := p.staging[identifier]
+	if current != nil && staging != nil && staging.Endpoint == current.Endpoint &&
+		staging.ReceivedFiles >= current.ReceivedFiles && staging.ReceivedBytes >= current.ReceivedBytes {
+		p.staging[identifier] = staging
+		return
+	}
+
+	// Otherwise, record the current staging operation (if any) as complete and
+	// start tracking the new one (if any).
+	if current != nil {
+		total := p.staged[identifier]
+		if total == nil {
+			total = &StagingSummary{}
+			p.staged[identifier] = total
+		}
+		total.ReceivedFiles += current.ReceivedFiles
+		total.ExpectedFiles += current.ExpectedFiles
+		total.ReceivedBytes += current.ReceivedBytes
+	}
+	if staging != nil {
+		p.staging[identifier] = staging
+	} else {
+		delete(p.staging, identifier)
+	}
+}
+
+// stagedTotals returns the total number of files and bytes observed to have
+// been staged by the specified sessions. It must only be called after tracking
+// has been stopped.
+func (p *sessionProgress) stagedTotals(identifiers []string) (files, bytes uint64) {
+	for _, identifier := range identifiers {
+		if total := p.staged[identifier]; total != nil {
+			files += total.ReceivedFiles
+			bytes += total.ReceivedBytes
+		}
+	}
+	return
 }
@@ -0,0 +1,143 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// transientRetryAttempts is the maximum number of attempts made for an
+	// operation that fails with transient errors.
+	transientRetryAttempts = 6
+	// transientRetryInitialDelay is the delay before the first retry of an
+	// operation that fails with a transient error.
+	transientRetryInitialDelay = 500 * time.Millisecond
+	// transientRetryMaximumDelay is the maximum delay between retries of an
+	// operation that fails with transient errors.
+	transientRetryMaximumDelay = 8 * time.Second
+)
+
+// agentDialErrorFragment is a fragment of the error reported by Mutagen when a
+// Docker-based endpoint fails to dial its agent. Only agent dial failures are
+// eligible to be considered transient, since other session creation failures
+// (e.g. invalid configuration) won't resolve on their own.
+const agentDialErrorFragment = "unable to dial agent endpoint"
+
+// transientAgentDialErrorFragments are error message fragments that indicate
+// that an agent dial failed because the Mutagen Compose sidecar container
+// wasn't ready yet (e.g. because it was still starting or restarting), in
+// which case a subsequent dial will succeed. They include both Docker's own
+// errors (which surface in agent handshake errors) and the Docker transport's
+// classification of container probing failures.
+var transientAgentDialErrorFragments = []string{
+	"connection refused",
+	"is not running",
+	"container not running",
+	"is restarting, wait until the container is running",
+}
+
+// rpcError is an error returned by a Mutagen daemon RPC with its RPC layer
+// peeled away. Unlike grpcutil.PeelAwayRPCErrorLayer, it preserves the gRPC
+// status code so that the error can be classified.
+type rpcError struct {
+	// code is the gRPC status code.
+	code codes.Code
+	// message is the error message.
+	message string
+}
+
+// Error implements error.Error.
+func (e *rpcError) Error() string {
+	return e.message
+}
+
+// peelAwayRPCErrorLayer peels away the RPC layer of an error returned by a
+// Mutagen daemon RPC, preserving its gRPC status code. Errors without an RPC
+// layer (including nil errors) are returned unmodified.
+func peelAwayRPCErrorLayer(err error) error {
+	if err == nil {
+		return nil
+	} else if s, ok := status.FromError(err); ok {
+		return &rpcError{code: s.Code(), message: s.Message()}
+	}
+	return err
+}
+
+// isTransientError determines whether or not an error returned by a Mutagen
+// daemon RPC indicates a transient failure that's worth retrying. Only failures
+// known to resolve on their own are considered transient, namely the daemon
+// being temporarily unavailable and agent dials failing because the sidecar
+// container isn't ready yet. Context cancellation and deadline errors are never
+// considered transient.
+func isTransientError(err error) bool {
+	// Handle nil errors and context errors.
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Check for RPC failures due to the daemon being unavailable. Errors from
+	// session operations themselves are reported with an unknown status code
+	// and a bare message.
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) {
+		return false
+	} else if rpcErr.code == codes.Unavailable {
+		return true
+	}
+
+	// Check for agent dial failures due to the sidecar container not being
+	// ready.
+	if !strings.Contains(rpcErr.message, agentDialErrorFragment) {
+		return false
+	}
+	message := strings.ToLower(rpcErr.message)
+	for _, fragment := range transientAgentDialErrorFragments {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransient performs an operation, retrying it with exponential backoff
+// if it fails with a transient error (as classified by isTransientError).
+// Permanent errors are returned immediately, as is the last error if all
+// attempts fail. Retries are reported as warnings via the status updater,
+// using the description to identify the operation. Waiting between attempts is
+// regulated by the context.
+func retryTransient(ctx context.Context, status *statusUpdater, description string, operation func() error) error {
+	delay := transientRetryInitialDelay
+	for attempt := 1; ; attempt++ {
+		// Perform the operation and handle success or permanent failure.
+		err := operation()
+		if err == nil || attempt == transientRetryAttempts || !isTransientError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		// Report the retry and wait, restoring the previous status afterward.
+		step := status.step
+		status.warning(fmt.Sprintf("Retrying %s in %s (attempt %d of %d): %v",
+			description, delay, attempt+1, transientRetryAttempts, err,
+		))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		status.working(step)
+
+		// Increase the delay.
+		delay *= 2
+		if delay > transientRetryMaximumDelay {
+			delay = transientRetryMaximumDelay
+		}
+	}
+}
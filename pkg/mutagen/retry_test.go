@@ -0,0 +1,83 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestPeelAwayRPCErrorLayer tests peelAwayRPCErrorLayer.
+func TestPeelAwayRPCErrorLayer(t *testing.T) {
+	plain := errors.New("plain error")
+	testCases := []struct {
+		description string
+		err         error
+		expectRPC   bool
+		code        codes.Code
+		message     string
+	}{
+		{"nil error", nil, false, codes.OK, ""},
+		{"non-RPC error", plain, false, codes.OK, "plain error"},
+		{"unknown RPC error", status.Error(codes.Unknown, "session creation failed"), true, codes.Unknown, "session creation failed"},
+		{"unavailable RPC error", status.Error(codes.Unavailable, "connection closed"), true, codes.Unavailable, "connection closed"},
+	}
+	for _, testCase := range testCases {
+		peeled := peelAwayRPCErrorLayer(testCase.err)
+		rpcErr, isRPC := peeled.(*rpcError)
+		if isRPC != testCase.expectRPC {
+			t.Errorf("%s: RPC layer classification mismatch: %t != %t", testCase.description, isRPC, testCase.expectRPC)
+			continue
+		}
+		if !isRPC {
+			if peeled != testCase.err {
+				t.Errorf("%s: non-RPC error was modified", testCase.description)
+			}
+			continue
+		}
+		if rpcErr.code != testCase.code {
+			t.Errorf("%s: code mismatch: %v != %v", testCase.description, rpcErr.code, testCase.code)
+		}
+		if rpcErr.Error() != testCase.message {
+			t.Errorf("%s: message mismatch: %q != %q", testCase.description, rpcErr.Error(), testCase.message)
+		}
+	}
+}
+
+// TestIsTransientError tests isTransientError.
+func TestIsTransientError(t *testing.T) {
+	dialFailure := func(cause string) error {
+		return peelAwayRPCErrorLayer(status.Error(codes.Unknown,
+			"unable to connect to beta: unable to dial agent endpoint: "+cause,
+		))
+	}
+	testCases := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{"nil error", nil, false},
+		{"context canceled", context.Canceled, false},
+		{"wrapped deadline exceeded", fmt.Errorf("operation failed: %w", context.DeadlineExceeded), false},
+		{"non-RPC error", errors.New("connection refused"), false},
+		{"daemon unavailable", peelAwayRPCErrorLayer(status.Error(codes.Unavailable, "connection closed")), true},
+		{"wrapped daemon unavailable", fmt.Errorf("unable to create session: %w",
+			peelAwayRPCErrorLayer(status.Error(codes.Unavailable, "connection closed")),
+		), true},
+		{"agent dial connection refused", dialFailure("dial unix /var/run/docker.sock: connect: connection refused"), true},
+		{"agent dial container not running", dialFailure("Error response from daemon: Container abc Is Not Running"), true},
+		{"agent dial container probe", dialFailure("unable to probe container: container not running"), true},
+		{"agent dial container restarting", dialFailure("Container abc is restarting, wait until the container is running"), true},
+		{"agent dial permanent failure", dialFailure("unable to find executable"), false},
+		{"non-dial connection refused", peelAwayRPCErrorLayer(status.Error(codes.Unknown, "unable to connect: connection refused")), false},
+		{"invalid configuration", peelAwayRPCErrorLayer(status.Error(codes.Unknown, "invalid synchronization mode")), false},
+	}
+	for _, testCase := range testCases {
+		if result := isTransientError(testCase.err); result != testCase.expected {
+			t.Errorf("%s: classification mismatch: %t != %t", testCase.description, result, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package mutagen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+)
+
+const (
+	// KeepPartialSessionsEnvironmentVariable is the environment variable that
+	// can be set to "1" to keep (rather than roll back) sessions created during
+	// a session reconciliation that subsequently fails.
+	KeepPartialSessionsEnvironmentVariable = "MUTAGEN_COMPOSE_KEEP_PARTIAL_SESSIONS"
+	// rollbackTimeout is the maximum amount of time to spend rolling back a
+	// failed session reconciliation. Rollback isn't subject to cancellation of
+	// the reconciliation's context, since it may have failed due to that
+	// cancellation.
+	rollbackTimeout = 30 * time.Second
+)
+
+// reconciliationChanges records the changes made during session reconciliation
+// so that they can be rolled back (and summarized) if reconciliation fails.
+type reconciliationChanges struct {
+	// pruned is the number of orphaned, duplicate, and stale sessions that were
+	// pruned. Pruned sessions can't be restored.
+	pruned int
+	// forwarding are the identifiers of forwarding sessions that were created.
+	forwarding []string
+	// synchronization are the identifiers of synchronization sessions that
+	// were created.
+	synchronization []string
+	// names are the names of the sessions that were created.
+	names []string
+	// committed indicates whether or not reconciliation has progressed past
+	// session creation to the retirement of previous sessions, after which
+	// created sessions can't be rolled back without losing sessions entirely.
+	committed bool
+}
+
+// createdForwarding records the creation of a forwarding session.
+func (c *reconciliationChanges) createdForwarding(name, identifier string) {
+	c.forwarding = append(c.forwarding, identifier)
+	c.names = append(c.names, name)
+}
+
+// createdSynchronization records the creation of a synchronization session.
+func (c *reconciliationChanges) createdSynchronization(name, identifier string) {
+	c.synchronization = append(c.synchronization, identifier)
+	c.names = append(c.names, name)
+}
+
+// prunedDescription returns a description of pruning, or an empty string if no
+// sessions were pruned.
+func (c *reconciliationChanges) prunedDescription() string {
+	if c.pruned == 0 {
+		return ""
+	}
+	return fmt.Sprintf("; %d stale session(s) pruned (not restorable)", c.pruned)
+}
+
+// rollbackReconciliation rolls back the sessions created during a failed
+// session reconciliation (unless rollback has been disabled or reconciliation
+// had already been committed) and reports a summary of the changes made and
+// rolled back.
+func (l *Liaison) rollbackReconciliation(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	prompter string,
+	changes *reconciliationChanges,
+) {
+	// Create a context for rollback and a status updater for the summary.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	summary := newStatusUpdater(ctx, "Mutagen rollback")
+
+	// If no sessions were created, then there's nothing to roll back.
+	if len(changes.names) == 0 {
+		if changes.pruned > 0 {
+			summary.warning(fmt.Sprintf("No sessions created%s", changes.prunedDescription()))
+		}
+		return
+	}
+	created := strings.Join(changes.names, ", ")
+
+	// Handle cases where sessions are kept.
+	if changes.committed {
+		summary.warning(fmt.Sprintf("Kept %d created session(s) (%s) since failure occurred after creation completed%s",
+			len(changes.names), created, changes.prunedDescription(),
+		))
+		return
+	} else if os.Getenv(KeepPartialSessionsEnvironmentVariable) == "1" {
+		summary.warning(fmt.Sprintf("Kept %d created session(s) (%s) as requested by %s%s",
+			len(changes.names), created, KeepPartialSessionsEnvironmentVariable, changes.prunedDescription(),
+		))
+		return
+	}
+
+	// Terminate created sessions.
+	summary.working(fmt.Sprintf("Rolling back %d created session(s)", len(changes.names)))
+	if len(changes.forwarding) > 0 {
+		rollbackSelection := &selection.Selection{Specifications: changes.forwarding}
+		if err := forwardingTerminateWithSelection(ctx, forwardingService, prompter, rollbackSelection); err != nil {
+			summary.error(fmt.Errorf("unable to roll back created forwarding sessions: %w", err))
+			return
+		}
+	}
+	if len(changes.synchronization) > 0 {
+		rollbackSelection := &selection.Selection{Specifications: changes.synchronization}
+		if err := synchronizationTerminateWithSelection(ctx, synchronizationService, prompter, rollbackSelection); err != nil {
+			summary.error(fmt.Errorf("unable to roll back created synchronization sessions: %w", err))
+			return
+		}
+	}
+	summary.done(fmt.Sprintf("Rolled back %d created session(s) (%s)%s",
+		len(changes.names), created, changes.prunedDescription(),
+	))
+}
@@ -0,0 +1,268 @@
+package mutagen
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mutagen-io/mutagen/pkg/forwarding"
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
+)
+
+const (
+	// pausedStatusDescription is the status description used for paused
+	// sessions.
+	pausedStatusDescription = "Paused"
+	// SessionKindForwarding is the session kind for forwarding sessions.
+	SessionKindForwarding = "forwarding"
+	// SessionKindSynchronization is the session kind for synchronization
+	// sessions.
+	SessionKindSynchronization = "synchronization"
+)
+
+// SessionSummary is a structured summary of a Mutagen session's state.
+type SessionSummary struct {
+	// Kind is the session kind (either SessionKindForwarding or
+	// SessionKindSynchronization).
+	Kind string `json:"kind"`
+	// Name is the session name.
+	Name string `json:"name"`
+	// Identifier is the session identifier.
+	Identifier string `json:"identifier"`
+	// Status is a human-readable description of the session status.
+	Status string `json:"status"`
+	// Endpoints are the formatted session endpoint URLs, in source/destination
+	// order for forwarding sessions and alpha/beta order for synchronization
+	// sessions.
+	Endpoints []string `json:"endpoints"`
+	// Conflicts is the number of synchronization conflicts (including those
+	// excluded from the session state). It is always zero for forwarding
+	// sessions.
+	Conflicts uint64 `json:"conflicts"`
+	// Problems is the number of scan and transition problems across both
+	// endpoints (including those excluded from the session state). It is
+	// always zero for forwarding sessions.
+	Problems uint64 `json:"problems"`
+	// SuccessfulCycles is the number of successful synchronization cycles
+	// performed by the session. It is always zero for forwarding sessions.
+	SuccessfulCycles uint64 `json:"successfulCycles"`
+	// LastError is the last error encountered by the session, if any.
+	LastError string `json:"lastError,omitempty"`
+	// Connected indicates whether or not each session endpoint is connected,
+	// in the same order as Endpoints.
+	Connected []bool `json:"connected"`
+	// Staging is the staging progress for synchronization sessions that are
+	// currently staging files. It is nil for all other sessions.
+	Staging *StagingSummary `json:"staging,omitempty"`
+}
+
+// StagingSummary is a structured summary of synchronization staging progress.
+type StagingSummary struct {
+	// Endpoint is the endpoint on which files are being staged (either "alpha"
+	// or "beta").
+	Endpoint string `json:"endpoint"`
+	// Path is the path currently being staged.
+	Path string `json:"path"`
+	// ReceivedFiles is the number of files that have been staged.
+	ReceivedFiles uint64 `json:"receivedFiles"`
+	// ExpectedFiles is the number of files expected to be staged.
+	ExpectedFiles uint64 `json:"expectedFiles"`
+	// ReceivedBytes is the total number of bytes that have been staged.
+	ReceivedBytes uint64 `json:"receivedBytes"`
+}
+
+// newStagingSummary creates a staging summary for a synchronization endpoint,
+// returning nil if the endpoint isn't currently staging files.
+func newStagingSummary(endpoint string, state *synchronization.EndpointState) *StagingSummary {
+	if state == nil || state.StagingProgress == nil {
+		return nil
+	}
+	return &StagingSummary{
+		Endpoint:      endpoint,
+		Path:          state.StagingProgress.Path,
+		ReceivedFiles: state.StagingProgress.ReceivedFiles,
+		ExpectedFiles: state.StagingProgress.ExpectedFiles,
+		ReceivedBytes: state.StagingProgress.TotalReceivedSize,
+	}
+}
+
+// newForwardingSessionSummary creates a session summary for a forwarding
+// session.
+func newForwardingSessionSummary(state *forwarding.State) SessionSummary {
+	status := state.Status.Description()
+	if state.Session.Paused {
+		status = pausedStatusDescription
+	}
+	return SessionSummary{
+		Kind:       SessionKindForwarding,
+		Name:       state.Session.Name,
+		Identifier: state.Session.Identifier,
+		Status:     status,
+		Endpoints: []string{
+			state.Session.Source.Format(""),
+			state.Session.Destination.Format(""),
+		},
+		LastError: state.LastError,
+		Connected: []bool{
+			state.Status > forwarding.Status_ConnectingSource,
+			state.Status == forwarding.Status_ForwardingConnections,
+		},
+	}
+}
+
+// countEndpointProblems counts the scan and transition problems for a
+// synchronization endpoint, including those excluded from the endpoint state.
+func countEndpointProblems(state *synchronization.EndpointState) uint64 {
+	if state == nil {
+		return 0
+	}
+	return uint64(len(state.ScanProblems)) + state.ExcludedScanProblems +
+		uint64(len(state.TransitionProblems)) + state.ExcludedTransitionProblems
+}
+
+// newSynchronizationSessionSummary creates a session summary for a
+// synchronization session.
+func newSynchronizationSessionSummary(state *synchronization.State) SessionSummary {
+	status := state.Status.Description()
+	if state.Session.Paused {
+		status = pausedStatusDescription
+	}
+	summary := SessionSummary{
+		Kind:       SessionKindSynchronization,
+		Name:       state.Session.Name,
+		Identifier: state.Session.Identifier,
+		Status:     status,
+		Endpoints: []string{
+			state.Session.Alpha.Format(""),
+			state.Session.Beta.Format(""),
+		},
+		Conflicts:        uint64(len(state.Conflicts)) + state.ExcludedConflicts,
+		Problems:         countEndpointProblems(state.AlphaState) + countEndpointProblems(state.BetaState),
+		SuccessfulCycles: state.SuccessfulCycles,
+		LastError:        state.LastError,
+		Connected: []bool{
+			state.AlphaState != nil && state.AlphaState.Connected,
+			state.BetaState != nil && state.BetaState.Connected,
+		},
+	}
+	if staging := newStagingSummary("alpha", state.AlphaState); staging != nil {
+		summary.Staging = staging
+	} else if staging = newStagingSummary("beta", state.BetaState); staging != nil {
+		summary.Staging = staging
+	}
+	return summary
+}
+
+// ProjectSessions returns structured summaries of the Mutagen sessions for the
+// specified project. If the project has no Mutagen Compose sidecar container,
+// then no sessions are returned and no connection to the Mutagen daemon is
+// established. This method must only be called after the Docker CLI has been
+// registered.
+func (l *Liaison) ProjectSessions(ctx context.Context, projectName string) ([]SessionSummary, error) {
+	// Perform a query to identify the Mutagen Compose sidecar container. We
+	// allow it to not exist, but we don't allow multiple matches.
+	sidecar, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return nil, err
+	} else if sidecar == nil {
+		return nil, nil
+	}
+
+	// Connect to the Mutagen daemon.
+	daemonConnection, err := l.sharedDaemonConnection(nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to Mutagen daemon: %w", err)
+	}
+
+	// Query the sessions.
+	return querySessionSummaries(ctx,
+		forwardingsvc.NewForwardingClient(daemonConnection),
+		synchronizationsvc.NewSynchronizationClient(daemonConnection),
+		sidecarSessionSelection(sidecar.ID), sidecar.ID,
+	)
+}
+
+// sessionSnapshot is a snapshot of session states.
+type sessionSnapshot struct {
+	// summaries are the session summaries, with synchronization sessions
+	// listed before forwarding sessions.
+	summaries []SessionSummary
+	// forwardingStateIndex is the forwarding state index at which the
+	// snapshot was taken.
+	forwardingStateIndex uint64
+	// synchronizationStateIndex is the synchronization state index at which
+	// the snapshot was taken.
+	synchronizationStateIndex uint64
+}
+
+// querySessionSummaries queries the sessions matching the specified selection
+// and returns their summaries, with synchronization sessions listed before
+// forwarding sessions. If sidecarID is non-empty, then session ownership is
+// verified.
+func querySessionSummaries(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	sidecarID string,
+) ([]SessionSummary, error) {
+	snapshot, err := querySessionSnapshot(ctx, forwardingService, synchronizationService, sessionSelection, sidecarID)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.summaries, nil
+}
+
+// querySessionSnapshot behaves like querySessionSummaries, but also returns the
+// state indices at which the sessions were queried.
+func querySessionSnapshot(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	sidecarID string,
+) (*sessionSnapshot, error) {
+	// Query forwarding sessions.
+	forwardingResponse, err := forwardingService.List(ctx, &forwardingsvc.ListRequest{Selection: sessionSelection})
+	if err != nil {
+		return nil, fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = forwardingResponse.EnsureValid(); err != nil {
+		return nil, fmt.Errorf("invalid forwarding session listing response received: %w", err)
+	}
+
+	// Query synchronization sessions.
+	synchronizationResponse, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: sessionSelection})
+	if err != nil {
+		return nil, fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = synchronizationResponse.EnsureValid(); err != nil {
+		return nil, fmt.Errorf("invalid synchronization session listing response received: %w", err)
+	}
+
+	// Verify that the sessions actually belong to the sidecar, if requested.
+	if sidecarID != "" {
+		if err := checkSidecarSessionOwnership(
+			forwardingResponse.SessionStates, synchronizationResponse.SessionStates, sidecarID,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert the session states to summaries.
+	summaries := make([]SessionSummary, 0, len(forwardingResponse.SessionStates)+len(synchronizationResponse.SessionStates))
+	for _, state := range synchronizationResponse.SessionStates {
+		summaries = append(summaries, newSynchronizationSessionSummary(state))
+	}
+	for _, state := range forwardingResponse.SessionStates {
+		summaries = append(summaries, newForwardingSessionSummary(state))
+	}
+
+	// Success.
+	return &sessionSnapshot{
+		summaries:                 summaries,
+		forwardingStateIndex:      forwardingResponse.StateIndex,
+		synchronizationStateIndex: synchronizationResponse.StateIndex,
+	}, nil
+}
@@ -1,16 +1,35 @@
 package mutagen
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"os"
+	"strings"
 
 	"github.com/spf13/pflag"
 
 	"github.com/docker/cli/cli/command"
 
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
 	"github.com/compose-spec/compose-go/types"
 
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/mutagen-io/mutagen/pkg/forwarding"
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
 	"github.com/mutagen-io/mutagen/pkg/mutagen"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	forwardingsvc "github.com/mutagen-io/mutagen/pkg/service/forwarding"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
 	"github.com/mutagen-io/mutagen/pkg/sidecar"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
 	"github.com/mutagen-io/mutagen/pkg/url"
 )
 
@@ -30,6 +49,9 @@ const (
 	// sidecarVersionLabelKey is the name of the label applied to the Mutagen
 	// Compose sidecar container to embed Mutagen Compose version information.
 	sidecarVersionLabelKey = "io.mutagen.compose.version"
+	// sidecarStatusEventID is the progress event identifier used for status
+	// updates related to the Mutagen Compose sidecar container itself.
+	sidecarStatusEventID = "Mutagen sidecar"
 )
 
 // sidecarImage is the full Mutagen sidecar image tag. This sidecar image will
@@ -142,6 +164,48 @@ func reifySidecarURLIfNecessary(target *url.URL, dockerFlags *pflag.FlagSet, doc
 	}
 }
 
+// sidecarTransportReference returns a reified sidecar URL (with no target
+// container) that can be used as a reference for the transport parameters that
+// would be used to target the Docker daemon that Compose is currently targeting.
+func sidecarTransportReference(dockerFlags *pflag.FlagSet, dockerCLI command.Cli) *url.URL {
+	reference := &url.URL{Protocol: sidecarURLProtocol}
+	reifySidecarURLIfNecessary(reference, dockerFlags, dockerCLI, "")
+	return reference
+}
+
+// sharesSidecarTransport checks whether or not a URL is a Docker URL using the
+// same transport parameters as the specified reference URL (as computed by
+// sidecarTransportReference).
+func sharesSidecarTransport(target, reference *url.URL) bool {
+	return target.Protocol == url.Protocol_Docker &&
+		maps.Equal(target.Parameters, reference.Parameters)
+}
+
+// sidecarURLsEquivalent determines whether or not an existing session URL is
+// equivalent to a specified session URL, ignoring the sidecar container that
+// they target. This can be used to identify sessions created for a previous
+// instance of a Mutagen Compose sidecar container.
+func sidecarURLsEquivalent(existing, specified *url.URL) bool {
+	// Ensure that both are non-nil.
+	if existing == nil || specified == nil {
+		return false
+	}
+
+	// If this isn't a Docker URL, then perform a standard comparison.
+	if existing.Protocol != url.Protocol_Docker {
+		return existing.Equal(specified)
+	}
+
+	// Otherwise perform an equivalence check that ignores the host.
+	return existing.Kind == specified.Kind &&
+		existing.Protocol == specified.Protocol &&
+		existing.User == specified.User &&
+		existing.Port == specified.Port &&
+		existing.Path == specified.Path &&
+		maps.Equal(existing.Environment, specified.Environment) &&
+		maps.Equal(existing.Parameters, specified.Parameters)
+}
+
 // isValidRestartPolicy returns true if and only if the provided restart policy
 // is non-empty and names a valid restart policy.
 func isValidRestartPolicy(restart string) bool {
@@ -150,3 +214,181 @@ func isValidRestartPolicy(restart string) bool {
 		restart == types.RestartPolicyNo ||
 		restart == types.RestartPolicyUnlessStopped
 }
+
+// isValidUpgradePolicy returns true if and only if the provided sidecar upgrade
+// policy is empty (indicating the default policy) or names a valid policy.
+func isValidUpgradePolicy(upgrade string) bool {
+	return upgrade == "" || upgrade == "auto" || upgrade == "never"
+}
+
+// findSidecarContainer queries the Docker daemon for the Mutagen Compose sidecar
+// container associated with the specified project. It returns nil if no sidecar
+// container exists, but returns an error if multiple sidecar containers exist.
+func (l *Liaison) findSidecarContainer(ctx context.Context, projectName string) (*moby.Container, error) {
+	containers, err := l.dockerCLI.Client().ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", fmt.Sprintf("%s=%s", api.ProjectLabel, projectName)),
+			filters.Arg("label", fmt.Sprintf("%s=%s", sidecarRoleLabelKey, sidecarRoleLabelValue)),
+		),
+		All: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query Mutagen sidecar container: %w", err)
+	} else if len(containers) > 1 {
+		return nil, errors.New("multiple Mutagen sidecar containers identified")
+	} else if len(containers) == 0 {
+		return nil, nil
+	}
+	l.recordSidecarLabels(containers[0].Labels)
+	return &containers[0], nil
+}
+
+// sidecarDrift determines whether or not the Mutagen Compose sidecar container
+// for the specified project (if any) was created by a different version of
+// Mutagen Compose or with a different sidecar image than the one that would be
+// used now. If a drift is detected, then a human-readable description of the
+// drift is returned, otherwise an empty string is returned. If the sidecar
+// service definition hasn't been computed by processProject, then either the
+// standard or SSPL-licensed sidecar image is considered current.
+func (l *Liaison) sidecarDrift(ctx context.Context, projectName string) (string, error) {
+	// Look up the sidecar container. If it doesn't exist, then there's nothing
+	// that can have drifted.
+	container, err := l.findSidecarContainer(ctx, projectName)
+	if err != nil {
+		return "", err
+	} else if container == nil {
+		return "", nil
+	}
+
+	// Check the version label. Sidecar containers created by very old versions
+	// of Mutagen Compose may not have this label.
+	existingVersion, ok := container.Labels[sidecarVersionLabelKey]
+	if !ok {
+		existingVersion = "unknown"
+	}
+	if existingVersion != mutagen.Version {
+		return fmt.Sprintf("version %s → %s", existingVersion, mutagen.Version), nil
+	}
+
+	// Check the image. The image reference recorded for a container may be
+	// reported as an image identifier (e.g. if the reference has since been
+	// re-tagged), so we compare image identifiers rather than references. If
+	// none of the expected images are available locally, then they can't be the
+	// image that the container is using.
+	expected := []string{sidecarImage, sidecarImage + ssplSidecarImageTagSuffix}
+	if l.mutagenService.Image != "" {
+		expected = []string{l.mutagenService.Image}
+	}
+	for _, reference := range expected {
+		image, _, err := l.dockerCLI.Client().ImageInspectWithRaw(ctx, reference)
+		if err != nil {
+			if client.IsErrNotFound(err) {
+				continue
+			}
+			return "", fmt.Errorf("unable to inspect Mutagen sidecar image (%s): %w", reference, err)
+		} else if image.ID == container.ImageID {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("image %s → %s", container.Image, expected[0]), nil
+}
+
+// targetsSidecar determines whether or not a session URL targets the specified
+// sidecar container.
+func targetsSidecar(target *url.URL, sidecarID string) bool {
+	return target != nil && target.Protocol == url.Protocol_Docker && target.Host == sidecarID
+}
+
+// checkSidecarSessionOwnership verifies that the specified sessions (which are
+// expected to have been selected using sidecarSessionSelection) all target the
+// specified sidecar container. Since sidecar label values are only a truncated
+// (or hashed) encoding of the sidecar container identifier, this guards against
+// operating on sessions belonging to a different sidecar container whose label
+// value happens to collide.
+func checkSidecarSessionOwnership(forwardingStates []*forwarding.State, synchronizationStates []*synchronization.State, sidecarID string) error {
+	var foreign []string
+	for _, state := range forwardingStates {
+		if !(targetsSidecar(state.Session.Source, sidecarID) || targetsSidecar(state.Session.Destination, sidecarID)) {
+			foreign = append(foreign, state.Session.Identifier)
+		}
+	}
+	for _, state := range synchronizationStates {
+		if !(targetsSidecar(state.Session.Alpha, sidecarID) || targetsSidecar(state.Session.Beta, sidecarID)) {
+			foreign = append(foreign, state.Session.Identifier)
+		}
+	}
+	if len(foreign) > 0 {
+		return fmt.Errorf("sidecar label selection matched sessions belonging to a different sidecar container (%s), refusing to operate on them",
+			strings.Join(foreign, ", "),
+		)
+	}
+	return nil
+}
+
+// ensureSidecarSessionOwnership queries the sessions matching the specified
+// selection and verifies that they all target the specified sidecar container
+// using checkSidecarSessionOwnership.
+func ensureSidecarSessionOwnership(
+	ctx context.Context,
+	forwardingService forwardingsvc.ForwardingClient,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	sessionSelection *selection.Selection,
+	sidecarID string,
+) error {
+	// Query forwarding sessions.
+	forwardingResponse, err := forwardingService.List(ctx, &forwardingsvc.ListRequest{Selection: sessionSelection})
+	if err != nil {
+		return fmt.Errorf("forwarding session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = forwardingResponse.EnsureValid(); err != nil {
+		return fmt.Errorf("invalid forwarding session listing response received: %w", err)
+	}
+
+	// Query synchronization sessions.
+	synchronizationResponse, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: sessionSelection})
+	if err != nil {
+		return fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+	} else if err = synchronizationResponse.EnsureValid(); err != nil {
+		return fmt.Errorf("invalid synchronization session listing response received: %w", err)
+	}
+
+	// Verify ownership.
+	return checkSidecarSessionOwnership(forwardingResponse.SessionStates, synchronizationResponse.SessionStates, sidecarID)
+}
+
+// runSidecarCommand runs a command inside the specified sidecar container and
+// waits for it to complete, returning an error (including the command's
+// output) if it fails.
+func (l *Liaison) runSidecarCommand(ctx context.Context, sidecarID string, command ...string) error {
+	// Create the process.
+	client := l.dockerCLI.Client()
+	execution, err := client.ContainerExecCreate(ctx, sidecarID, moby.ExecConfig{
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create sidecar process: %w", err)
+	}
+
+	// Run the process and wait for it to complete.
+	attachment, err := client.ContainerExecAttach(ctx, execution.ID, moby.ExecStartCheck{})
+	if err != nil {
+		return fmt.Errorf("unable to start sidecar process: %w", err)
+	}
+	defer attachment.Close()
+	output := &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(output, output, attachment.Reader); err != nil {
+		return fmt.Errorf("unable to read sidecar process output: %w", err)
+	}
+
+	// Verify that the process succeeded.
+	inspection, err := client.ContainerExecInspect(ctx, execution.ID)
+	if err != nil {
+		return fmt.Errorf("unable to inspect sidecar process: %w", err)
+	} else if inspection.ExitCode != 0 {
+		return fmt.Errorf("sidecar process (%s) failed with exit code %d: %s",
+			command[0], inspection.ExitCode, strings.TrimSpace(output.String()),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package mutagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose/v2/pkg/progress"
+
+	"github.com/mutagen-io/mutagen/pkg/grpcutil"
+	"github.com/mutagen-io/mutagen/pkg/selection"
+	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization"
+)
+
+// volumeArchivePath returns the path used to archive the contents of a volume
+// inside the sidecar container. The trailing "." component causes archives to
+// contain the volume contents rather than the volume directory itself.
+func volumeArchivePath(volumePath string) string {
+	if strings.HasPrefix(volumePath, "/") {
+		return volumePath + "/."
+	}
+	return volumePath + `\.`
+}
+
+// volumeOperation is an operation performed on the sidecar volume of a
+// synchronization session. It receives the synchronization service client, the
+// prompter, the session state, the sidecar container identifier, and the path
+// of the volume inside the sidecar container.
+type volumeOperation func(
+	ctx context.Context,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	prompter string,
+	state *synchronization.State,
+	sidecarID, volumePath string,
+) error
+
+// performVolumeOperation performs an operation on the sidecar volume of the
+// named synchronization session for the specified project. The session is
+// paused for the duration of the operation. If the session wasn't already
+// paused, then it is resumed when the operation completes (even if the
+// operation fails) and, if requested, flushed. The title is used as both the
+// progress title and the working status, and the done description is used
+// once the operation completes.
+func (l *Liaison) performVolumeOperation(
+	ctx context.Context,
+	projectName, name string,
+	title, done string,
+	flush bool,
+	operation volumeOperation,
+) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		// Apply the operation timeout and defer cancellation of the operation
+		// context.
+		ctx, cancel, err := l.operationContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		// Create a Mutagen status updater, start the Mutagen status update,
+		// and defer its finalization.
+		status := newStatusUpdater(ctx, "Mutagen")
+		status.working(fmt.Sprintf("%s synchronization session \"%s\"", title, name))
+		var statusErr error
+		defer func() {
+			if statusErr != nil {
+				status.error(statusErr)
+			} else {
+				status.done(done)
+			}
+		}()
+
+		// Lock the project (to serialize operations with other Mutagen Compose
+		// invocations) and defer release of the lock.
+		ctx, unlock, err := l.lockProject(ctx, status, projectName)
+		if err != nil {
+			statusErr = fmt.Errorf("unable to lock project: %w", err)
+			return statusErr
+		}
+		defer unlock()
+
+		// Open session control and defer its release.
+		control, release, err := l.openSessionControl(ctx, projectName, status)
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		defer release()
+		synchronizationService, prompter, sidecarID := control.synchronizationService, control.prompter, control.sidecarID
+
+		// Resolve and query the session.
+		sessionSelection, err := control.resolveSelection(ctx, SessionKindSynchronization, []string{name})
+		if err != nil {
+			statusErr = err
+			return statusErr
+		}
+		status.interruptionDetails = l.describeSessionStates(sessionSelection)
+		response, err := synchronizationService.List(ctx, &synchronizationsvc.ListRequest{Selection: sessionSelection})
+		if err != nil {
+			statusErr = fmt.Errorf("synchronization session listing failed: %w", grpcutil.PeelAwayRPCErrorLayer(err))
+			return statusErr
+		} else if err = response.EnsureValid(); err != nil {
+			statusErr = fmt.Errorf("invalid synchronization session listing response received: %w", err)
+			return statusErr
+		} else if len(response.SessionStates) != 1 {
+			statusErr = errors.New("unexpected number of synchronization sessions returned")
+			return statusErr
+		}
+		state := response.SessionStates[0]
+
+		// Identify the volume endpoint.
+		volume := state.Session.Beta
+		if targetsSidecar(state.Session.Alpha, sidecarID) {
+			volume = state.Session.Alpha
+		} else if !targetsSidecar(volume, sidecarID) {
+			statusErr = errors.New("synchronization session has no sidecar volume endpoint")
+			return statusErr
+		}
+
+		// Pause the session (if necessary).
+		paused := state.Session.Paused
+		if !paused {
+			status.working("Pausing synchronization session")
+			if err := synchronizationPauseWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+				statusErr = fmt.Errorf("unable to pause synchronization session: %w", err)
+				return statusErr
+			}
+		}
+
+		// Perform the operation.
+		status.working(fmt.Sprintf("%s volume contents", title))
+		operationErr := operation(ctx, synchronizationService, prompter, state, sidecarID, volume.Path)
+
+		// Resume the session (if necessary), regardless of the outcome of the
+		// operation. Like rollback, resumption isn't subject to cancellation of
+		// the operation's context, since the operation may have failed due to
+		// that cancellation.
+		if !paused {
+			status.working("Resuming synchronization session")
+			resumeCtx, resumeCancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+			err := synchronizationResumeWithSelection(resumeCtx, synchronizationService, prompter, sessionSelection)
+			resumeCancel()
+			if err != nil && operationErr == nil {
+				operationErr = fmt.Errorf("unable to resume synchronization session: %w", err)
+			}
+		}
+		if operationErr != nil {
+			statusErr = operationErr
+			return statusErr
+		}
+
+		// Flush the session, if requested. Paused sessions can't be flushed.
+		if flush && !paused {
+			status.working("Flushing synchronization session")
+			if err := synchronizationFlushWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+				statusErr = fmt.Errorf("unable to flush synchronization session: %w", err)
+				return statusErr
+			}
+		}
+
+		// Success.
+		return nil
+	}, l.dockerCLI.Err(), title)
+}
+
+// SnapshotSynchronizationSession writes a tar archive of the sidecar volume
+// contents for the named synchronization session in the specified project.
+// The session is paused while the archive is being written. This method must
+// only be called after the Docker CLI has been registered.
+func (l *Liaison) SnapshotSynchronizationSession(ctx context.Context, projectName, name string, out io.Writer) error {
+	return l.performVolumeOperation(ctx, projectName, name, "Snapshotting", "Snapshotted", false,
+		func(ctx context.Context, _ synchronizationsvc.SynchronizationClient, _ string, _ *synchronization.State, sidecarID, volumePath string) error {
+			// Stream the volume contents out of the sidecar container.
+			archive, _, err := l.dockerCLI.Client().CopyFromContainer(ctx, sidecarID, volumeArchivePath(volumePath))
+			if err != nil {
+				return fmt.Errorf("unable to copy volume contents from sidecar container: %w", err)
+			}
+			defer archive.Close()
+			if _, err := io.Copy(out, archive); err != nil {
+				return fmt.Errorf("unable to write snapshot: %w", err)
+			}
+			return nil
+		},
+	)
+}
+
+// RestoreSynchronizationSession replaces the sidecar volume contents for the
+// named synchronization session in the specified project with the contents of
+// a tar archive (such as one created by SnapshotSynchronizationSession). The
+// session is paused during restoration and is then reset and (unless it was
+// already paused) flushed so that synchronization restarts from the restored
+// contents. This method must only
+// be called after the Docker CLI has been registered.
+func (l *Liaison) RestoreSynchronizationSession(ctx context.Context, projectName, name string, in io.Reader) error {
+	return l.performVolumeOperation(ctx, projectName, name, "Restoring", "Restored", true,
+		func(ctx context.Context, synchronizationService synchronizationsvc.SynchronizationClient, prompter string, state *synchronization.State, sidecarID, volumePath string) error {
+			// We only support clearing volumes in POSIX sidecar containers.
+			if !strings.HasPrefix(volumePath, "/") {
+				return errors.New("restoration is only supported with Linux sidecar containers")
+			}
+
+			// Clear the existing volume contents (including hidden content).
+			if err := l.runSidecarCommand(ctx, sidecarID,
+				"sh", "-c", `rm -rf -- "$1"/* "$1"/.[!.]* "$1"/..?*`, "sh", volumePath,
+			); err != nil {
+				return fmt.Errorf("unable to clear volume contents: %w", err)
+			}
+
+			// Stream the archive contents into the sidecar container.
+			if err := l.dockerCLI.Client().CopyToContainer(ctx, sidecarID, volumePath, in, moby.CopyToContainerOptions{
+				CopyUIDGID: true,
+			}); err != nil {
+				return fmt.Errorf("unable to copy snapshot contents to sidecar container: %w", err)
+			}
+
+			// Reset the session so that its synchronization history doesn't
+			// cause the restored contents to be treated as modifications.
+			sessionSelection := &selection.Selection{Specifications: []string{state.Session.Identifier}}
+			if err := synchronizationResetWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+				return fmt.Errorf("unable to reset synchronization session: %w", err)
+			}
+
+			// Success.
+			return nil
+		},
+	)
+}
@@ -0,0 +1,203 @@
+package mutagen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+
+	"github.com/docker/compose/v2/pkg/progress"
+)
+
+const (
+	// SummaryFormatEnvironmentVariable is the environment variable that can be
+	// used to control the format of the summary reported at the end of session
+	// reconciliation. Supported values are "text" (the default), "json" (a
+	// single line of JSON), and "none".
+	SummaryFormatEnvironmentVariable = "MUTAGEN_COMPOSE_SUMMARY_FORMAT"
+)
+
+// SessionChange describes a change made to a session during reconciliation.
+type SessionChange struct {
+	// Kind is the session kind (either SessionKindForwarding or
+	// SessionKindSynchronization).
+	Kind string `json:"kind"`
+	// Name is the session name.
+	Name string `json:"name"`
+	// Reason is the reason for the change, if applicable.
+	Reason string `json:"reason,omitempty"`
+}
+
+// String formats the change for human-readable display.
+func (c SessionChange) String() string {
+	kind := "sync"
+	if c.Kind == SessionKindForwarding {
+		kind = "forward"
+	}
+	if c.Reason != "" {
+		return fmt.Sprintf("%s %s (%s)", kind, c.Name, c.Reason)
+	}
+	return kind + " " + c.Name
+}
+
+// ReconciliationPhase records the time spent in a phase of reconciliation.
+type ReconciliationPhase struct {
+	// Name is the phase name.
+	Name string `json:"name"`
+	// Seconds is the time spent in the phase.
+	Seconds float64 `json:"seconds"`
+}
+
+// ReconciliationSummary summarizes the changes made during a successful session
+// reconciliation. It is reported at the end of reconciliation.
+type ReconciliationSummary struct {
+	// Created are the sessions that were created.
+	Created []SessionChange `json:"created"`
+	// Recreated are the sessions that were recreated, along with the reason.
+	Recreated []SessionChange `json:"recreated"`
+	// Pruned are the sessions that were pruned, along with the reason.
+	Pruned []SessionChange `json:"pruned"`
+	// Resumed are the existing sessions that were resumed from a paused state.
+	Resumed []SessionChange `json:"resumed"`
+	// Flushed are the synchronization sessions that were flushed.
+	Flushed []SessionChange `json:"flushed"`
+	// StagedFiles is the number of files observed to have been staged while
+	// flushing. It is approximate (a lower bound) since staging progress is
+	// sampled.
+	StagedFiles uint64 `json:"stagedFiles"`
+	// StagedBytes is the number of bytes observed to have been staged while
+	// flushing. It is approximate (a lower bound) since staging progress is
+	// sampled.
+	StagedBytes uint64 `json:"stagedBytes"`
+	// Phases are the phases of reconciliation, in order.
+	Phases []ReconciliationPhase `json:"phases"`
+	// Seconds is the total time spent in reconciliation.
+	Seconds float64 `json:"seconds"`
+
+	// start is the time at which reconciliation started.
+	start time.Time
+	// phaseStart is the time at which the current phase started.
+	phaseStart time.Time
+}
+
+// newReconciliationSummary creates a new reconciliation summary, starting its
+// timing with the specified phase.
+func newReconciliationSummary(phase string) *ReconciliationSummary {
+	now := time.Now()
+	return &ReconciliationSummary{
+		Created:    []SessionChange{},
+		Recreated:  []SessionChange{},
+		Pruned:     []SessionChange{},
+		Resumed:    []SessionChange{},
+		Flushed:    []SessionChange{},
+		Phases:     []ReconciliationPhase{{Name: phase}},
+		start:      now,
+		phaseStart: now,
+	}
+}
+
+// phase completes timing of the current phase and starts timing of the next.
+func (s *ReconciliationSummary) phase(name string) {
+	now := time.Now()
+	s.Phases[len(s.Phases)-1].Seconds = now.Sub(s.phaseStart).Seconds()
+	s.Phases = append(s.Phases, ReconciliationPhase{Name: name})
+	s.phaseStart = now
+}
+
+// finish completes timing of the current phase and of reconciliation as a
+// whole and sorts session changes for display.
+func (s *ReconciliationSummary) finish() {
+	for _, changes := range [][]SessionChange{s.Created, s.Recreated, s.Pruned, s.Resumed, s.Flushed} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].Kind != changes[j].Kind {
+				return changes[i].Kind > changes[j].Kind
+			}
+			return changes[i].Name < changes[j].Name
+		})
+	}
+	now := time.Now()
+	s.Phases[len(s.Phases)-1].Seconds = now.Sub(s.phaseStart).Seconds()
+	s.Seconds = now.Sub(s.start).Seconds()
+}
+
+// formatSessionChanges formats a list of session changes for human-readable
+// display.
+func formatSessionChanges(changes []SessionChange) string {
+	formatted := make([]string, len(changes))
+	for c, change := range changes {
+		formatted[c] = change.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatSeconds formats a duration specified in seconds for human-readable
+// display.
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// lines formats the summary for human-readable display.
+func (s *ReconciliationSummary) lines() []string {
+	// Handle the case that no changes were made.
+	if len(s.Created)+len(s.Recreated)+len(s.Pruned)+len(s.Resumed)+len(s.Flushed) == 0 {
+		return []string{fmt.Sprintf("Mutagen sessions up to date (%s)", formatSeconds(s.Seconds))}
+	}
+
+	// Format changes.
+	lines := []string{fmt.Sprintf("Mutagen reconciliation summary (%s):", formatSeconds(s.Seconds))}
+	for _, category := range []struct {
+		label   string
+		changes []SessionChange
+	}{
+		{"created", s.Created},
+		{"recreated", s.Recreated},
+		{"pruned", s.Pruned},
+		{"resumed", s.Resumed},
+		{"flushed", s.Flushed},
+	} {
+		if len(category.changes) > 0 {
+			lines = append(lines, fmt.Sprintf("  %s: %s", category.label, formatSessionChanges(category.changes)))
+		}
+	}
+	if len(s.Flushed) > 0 {
+		lines = append(lines, fmt.Sprintf("  staged (approx.): at least %d files, %s",
+			s.StagedFiles, units.HumanSize(float64(s.StagedBytes)),
+		))
+	}
+
+	// Format phase timings.
+	phases := make([]string, len(s.Phases))
+	for p, phase := range s.Phases {
+		phases[p] = fmt.Sprintf("%s %s", phase.Name, formatSeconds(phase.Seconds))
+	}
+	lines = append(lines, "  phases: "+strings.Join(phases, ", "))
+
+	// Done.
+	return lines
+}
+
+// report reports the summary at the end of the Compose progress output in the
+// format specified by SummaryFormatEnvironmentVariable. Reporting the summary
+// via the progress writer ensures that it isn't overwritten by progress
+// rendering, but also means that it's omitted in quiet progress mode.
+func (s *ReconciliationSummary) report(writer progress.Writer) {
+	switch format := os.Getenv(SummaryFormatEnvironmentVariable); format {
+	case "", "text":
+		for _, line := range s.lines() {
+			writer.TailMsgf("%s", line)
+		}
+	case "json":
+		if encoded, err := json.Marshal(s); err != nil {
+			writer.TailMsgf("Unable to encode Mutagen reconciliation summary: %v", err)
+		} else {
+			writer.TailMsgf("%s", encoded)
+		}
+	case "none":
+	default:
+		writer.TailMsgf("Unknown %s value: %s", SummaryFormatEnvironmentVariable, format)
+	}
+}
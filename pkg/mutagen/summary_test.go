@@ -0,0 +1,137 @@
+package mutagen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/docker/compose/v2/pkg/progress"
+)
+
+// tailRecorder is a progress.Writer that records tail messages.
+type tailRecorder struct {
+	// messages are the recorded tail messages.
+	messages []string
+}
+
+// Start implements progress.Writer.Start.
+func (r *tailRecorder) Start(_ context.Context) error {
+	return nil
+}
+
+// Stop implements progress.Writer.Stop.
+func (r *tailRecorder) Stop() {}
+
+// Event implements progress.Writer.Event.
+func (r *tailRecorder) Event(_ progress.Event) {}
+
+// Events implements progress.Writer.Events.
+func (r *tailRecorder) Events(_ []progress.Event) {}
+
+// TailMsgf implements progress.Writer.TailMsgf.
+func (r *tailRecorder) TailMsgf(format string, arguments ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, arguments...))
+}
+
+// testReconciliationSummary creates a finished summary with fixed timings for
+// testing.
+func testReconciliationSummary(flushed bool) *ReconciliationSummary {
+	summary := newReconciliationSummary("prune")
+	summary.phase("create")
+	summary.Created = append(summary.Created,
+		SessionChange{Kind: SessionKindSynchronization, Name: "code"},
+		SessionChange{Kind: SessionKindForwarding, Name: "web"},
+	)
+	summary.Pruned = append(summary.Pruned,
+		SessionChange{Kind: SessionKindSynchronization, Name: "old", Reason: "no longer defined"},
+	)
+	if flushed {
+		summary.Flushed = append(summary.Flushed, SessionChange{Kind: SessionKindSynchronization, Name: "code"})
+		summary.StagedFiles = 3
+		summary.StagedBytes = 2000
+	}
+	summary.finish()
+	summary.Phases[0].Seconds = 0.5
+	summary.Phases[1].Seconds = 1.25
+	summary.Seconds = 1.75
+	return summary
+}
+
+// TestReconciliationSummaryLines tests ReconciliationSummary.lines.
+func TestReconciliationSummaryLines(t *testing.T) {
+	upToDate := newReconciliationSummary("prune")
+	upToDate.finish()
+	upToDate.Seconds = 0.25
+
+	testCases := []struct {
+		description string
+		summary     *ReconciliationSummary
+		expected    []string
+	}{
+		{"no changes", upToDate, []string{"Mutagen sessions up to date (250ms)"}},
+		{"changes", testReconciliationSummary(false), []string{
+			"Mutagen reconciliation summary (1.75s):",
+			"  created: sync code, forward web",
+			"  pruned: sync old (no longer defined)",
+			"  phases: prune 500ms, create 1.25s",
+		}},
+		{"changes with flushing", testReconciliationSummary(true), []string{
+			"Mutagen reconciliation summary (1.75s):",
+			"  created: sync code, forward web",
+			"  pruned: sync old (no longer defined)",
+			"  flushed: sync code",
+			"  staged (approx.): at least 3 files, 2kB",
+			"  phases: prune 500ms, create 1.25s",
+		}},
+	}
+	for _, testCase := range testCases {
+		if lines := testCase.summary.lines(); !reflect.DeepEqual(lines, testCase.expected) {
+			t.Errorf("%s: lines mismatch:\n%q\n!=\n%q", testCase.description, lines, testCase.expected)
+		}
+	}
+}
+
+// TestReconciliationSummaryReport tests ReconciliationSummary.report.
+func TestReconciliationSummaryReport(t *testing.T) {
+	summary := testReconciliationSummary(true)
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal("unable to encode summary:", err)
+	}
+
+	testCases := []struct {
+		format   string
+		expected []string
+	}{
+		{"", summary.lines()},
+		{"text", summary.lines()},
+		{"json", []string{string(encoded)}},
+		{"none", nil},
+		{"yaml", []string{"Unknown " + SummaryFormatEnvironmentVariable + " value: yaml"}},
+	}
+	for _, testCase := range testCases {
+		t.Setenv(SummaryFormatEnvironmentVariable, testCase.format)
+		recorder := &tailRecorder{}
+		summary.report(recorder)
+		if !reflect.DeepEqual(recorder.messages, testCase.expected) {
+			t.Errorf("%q: output mismatch:\n%q\n!=\n%q", testCase.format, recorder.messages, testCase.expected)
+		}
+	}
+
+	// Verify that JSON output decodes to the original summary.
+	t.Setenv(SummaryFormatEnvironmentVariable, "json")
+	recorder := &tailRecorder{}
+	summary.report(recorder)
+	var decoded ReconciliationSummary
+	if len(recorder.messages) != 1 {
+		t.Fatal("unexpected JSON output line count:", len(recorder.messages))
+	} else if err := json.Unmarshal([]byte(recorder.messages[0]), &decoded); err != nil {
+		t.Fatal("unable to decode JSON output:", err)
+	}
+	if decoded.Seconds != summary.Seconds || decoded.StagedFiles != summary.StagedFiles ||
+		!reflect.DeepEqual(decoded.Created, summary.Created) || !reflect.DeepEqual(decoded.Phases, summary.Phases) {
+		t.Errorf("decoded JSON output mismatch: %+v", decoded)
+	}
+}
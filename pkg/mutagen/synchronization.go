@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/mutagen-io/mutagen/pkg/grpcutil"
 	"github.com/mutagen-io/mutagen/pkg/selection"
 	synchronizationsvc "github.com/mutagen-io/mutagen/pkg/service/synchronization"
 	"github.com/mutagen-io/mutagen/pkg/synchronization"
+	"github.com/mutagen-io/mutagen/pkg/synchronization/core"
 	"github.com/mutagen-io/mutagen/pkg/url"
 )
 
@@ -82,12 +85,224 @@ func synchronizationSessionCurrent(
 	specification *synchronizationsvc.CreationSpecification,
 ) bool {
 	return session.Alpha.Equal(specification.Alpha) &&
-		session.Beta.Equal(session.Beta) &&
+		session.Beta.Equal(specification.Beta) &&
 		session.Configuration.Equal(specification.Configuration) &&
 		session.ConfigurationAlpha.Equal(specification.ConfigurationAlpha) &&
 		session.ConfigurationBeta.Equal(specification.ConfigurationBeta)
 }
 
+// synchronizationSessionReplaceable determines whether or not an existing
+// synchronization session created for a previous instance of the Mutagen Compose
+// sidecar container is equivalent to the specification for its creation, apart
+// from the sidecar container that it targets.
+func synchronizationSessionReplaceable(
+	session *synchronization.Session,
+	specification *synchronizationsvc.CreationSpecification,
+) bool {
+	return sidecarURLsEquivalent(session.Alpha, specification.Alpha) &&
+		sidecarURLsEquivalent(session.Beta, specification.Beta) &&
+		session.Configuration.Equal(specification.Configuration) &&
+		session.ConfigurationAlpha.Equal(specification.ConfigurationAlpha) &&
+		session.ConfigurationBeta.Equal(specification.ConfigurationBeta)
+}
+
+const (
+	// synchronizationSeedNone indicates that no initial seeding should be
+	// performed for a synchronization session.
+	synchronizationSeedNone = "none"
+	// synchronizationSeedAlpha indicates that a synchronization session should
+	// be seeded from alpha to beta when first created.
+	synchronizationSeedAlpha = "alpha"
+	// synchronizationSeedBeta indicates that a synchronization session should
+	// be seeded from beta to alpha when first created.
+	synchronizationSeedBeta = "beta"
+	// synchronizationSeedNameSuffix is the suffix added to the name of the
+	// temporary session used to seed a synchronization session. The temporary
+	// session is also labeled as such using the seed purpose. If seeding is
+	// interrupted, then the temporary session will be pruned by the next
+	// reconciliation.
+	synchronizationSeedNameSuffix = "-seed"
+	// synchronizationSeedPurpose is the temporary session label value used for
+	// seeding sessions.
+	synchronizationSeedPurpose = "seed"
+)
+
+const (
+	// synchronizationLifecyclePersistent indicates that a synchronization
+	// session should persist for the lifetime of the sidecar container.
+	synchronizationLifecyclePersistent = "persistent"
+	// synchronizationLifecycleOneshot indicates that a synchronization session
+	// should be flushed once and then terminated when the sidecar container
+	// starts.
+	synchronizationLifecycleOneshot = "oneshot"
+	// synchronizationPullNameSuffix is the suffix added to the name of the
+	// temporary sessions used to pull content from a synchronization session's
+	// volume. The temporary sessions are also labeled as such using the pull
+	// purpose. If pulling is interrupted, then the temporary session will be
+	// pruned by the next reconciliation.
+	synchronizationPullNameSuffix = "-pull"
+	// synchronizationPullPurpose is the temporary session label value used for
+	// pulling sessions.
+	synchronizationPullPurpose = "pull"
+)
+
+// isValidSynchronizationLifecycle determines whether or not a synchronization
+// lifecycle specification is valid.
+func isValidSynchronizationLifecycle(lifecycle string) bool {
+	switch lifecycle {
+	case "", synchronizationLifecyclePersistent, synchronizationLifecycleOneshot:
+		return true
+	default:
+		return false
+	}
+}
+
+// synchronizationURLWithSubpath returns a copy of a synchronization URL that
+// targets the specified subpath (which must be a slash-separated relative path
+// or empty) of the original URL's path.
+func synchronizationURLWithSubpath(target *url.URL, subpath string) *url.URL {
+	result := &url.URL{
+		Kind:        target.Kind,
+		Protocol:    target.Protocol,
+		User:        target.User,
+		Host:        target.Host,
+		Port:        target.Port,
+		Path:        target.Path,
+		Environment: target.Environment,
+		Parameters:  target.Parameters,
+	}
+	if subpath == "" {
+		return result
+	}
+	if target.Protocol == url.Protocol_Local {
+		result.Path = filepath.Join(target.Path, filepath.FromSlash(subpath))
+	} else if strings.HasPrefix(target.Path, "/") {
+		result.Path = path.Join(target.Path, subpath)
+	} else {
+		result.Path = target.Path + `\` + strings.ReplaceAll(subpath, "/", `\`)
+	}
+	return result
+}
+
+// synchronizationPullSpecification computes the specification for a temporary
+// one-way session that copies the specified subpath of a synchronization
+// session's volume endpoint to its local endpoint. If replace is false, then
+// the session uses one-way-safe mode, which leaves local content that's absent
+// from (or conflicts with) the volume in place. If replace is true, then the
+// session uses one-way-replica mode, which makes the local content an exact
+// copy of the volume content, deleting and overwriting local files as needed.
+func synchronizationPullSpecification(
+	specification *synchronizationsvc.CreationSpecification,
+	sidecarID string,
+	subpath string,
+	replace bool,
+) *synchronizationsvc.CreationSpecification {
+	mode := core.SynchronizationMode_SynchronizationModeOneWaySafe
+	if replace {
+		mode = core.SynchronizationMode_SynchronizationModeOneWayReplica
+	}
+	volume, local := specification.Alpha, specification.Beta
+	volumeConfiguration, localConfiguration := specification.ConfigurationAlpha, specification.ConfigurationBeta
+	if !targetsSidecar(volume, sidecarID) {
+		volume, local = local, volume
+		volumeConfiguration, localConfiguration = localConfiguration, volumeConfiguration
+	}
+	return &synchronizationsvc.CreationSpecification{
+		Alpha:              synchronizationURLWithSubpath(volume, subpath),
+		Beta:               synchronizationURLWithSubpath(local, subpath),
+		Configuration:      synchronization.MergeConfigurations(specification.Configuration, &synchronization.Configuration{SynchronizationMode: mode}),
+		ConfigurationAlpha: volumeConfiguration,
+		ConfigurationBeta:  localConfiguration,
+		Name:               specification.Name + synchronizationPullNameSuffix,
+		Labels:             temporarySessionLabels(specification.Labels, synchronizationPullPurpose),
+	}
+}
+
+// isValidSynchronizationSeed determines whether or not a synchronization seed
+// specification is valid.
+func isValidSynchronizationSeed(seed string) bool {
+	switch seed {
+	case "", synchronizationSeedNone, synchronizationSeedAlpha, synchronizationSeedBeta:
+		return true
+	default:
+		return false
+	}
+}
+
+// isOneWaySynchronizationMode determines whether or not a synchronization mode
+// is a one-way mode.
+func isOneWaySynchronizationMode(mode core.SynchronizationMode) bool {
+	return mode == core.SynchronizationMode_SynchronizationModeOneWaySafe ||
+		mode == core.SynchronizationMode_SynchronizationModeOneWayReplica
+}
+
+// synchronizationSeedSpecification computes the specification for a temporary
+// one-way-replica session that seeds the specified synchronization session in
+// the specified direction. For beta seeding, the endpoints (and their
+// endpoint-specific configurations) are swapped.
+func synchronizationSeedSpecification(
+	specification *synchronizationsvc.CreationSpecification,
+	seed string,
+) *synchronizationsvc.CreationSpecification {
+	result := &synchronizationsvc.CreationSpecification{
+		Alpha:              specification.Alpha,
+		Beta:               specification.Beta,
+		Configuration:      synchronization.MergeConfigurations(specification.Configuration, &synchronization.Configuration{SynchronizationMode: core.SynchronizationMode_SynchronizationModeOneWayReplica}),
+		ConfigurationAlpha: specification.ConfigurationAlpha,
+		ConfigurationBeta:  specification.ConfigurationBeta,
+		Name:               specification.Name + synchronizationSeedNameSuffix,
+		Labels:             temporarySessionLabels(specification.Labels, synchronizationSeedPurpose),
+	}
+	if seed == synchronizationSeedBeta {
+		result.Alpha, result.Beta = result.Beta, result.Alpha
+		result.ConfigurationAlpha, result.ConfigurationBeta = result.ConfigurationBeta, result.ConfigurationAlpha
+	}
+	return result
+}
+
+// synchronizationRunOnceWithSpecification creates a temporary synchronization
+// session using the specified specification, flushes it, and then terminates
+// it. The temporary session is terminated even if flushing fails (including due
+// to cancellation of the context). Creation of the session is retried if it
+// fails with a transient error, with retries reported via the status updater.
+// Flushing isn't retried, since the session has already been created.
+func synchronizationRunOnceWithSpecification(
+	ctx context.Context,
+	status *statusUpdater,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	prompter string,
+	specification *synchronizationsvc.CreationSpecification,
+) (err error) {
+	// Create the session.
+	var session string
+	if err := retryTransient(ctx, status, "temporary synchronization session creation", func() (err error) {
+		session, err = synchronizationCreateWithSpecification(ctx, synchronizationService, prompter, specification)
+		return err
+	}); err != nil {
+		return fmt.Errorf("unable to create session: %w", err)
+	}
+
+	// Ensure that the session is terminated. Like rollback, termination isn't
+	// subject to cancellation of the context, since flushing may have failed
+	// due to that cancellation.
+	sessionSelection := &selection.Selection{Specifications: []string{session}}
+	defer func() {
+		terminateCtx, terminateCancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer terminateCancel()
+		if terminateErr := synchronizationTerminateWithSelection(terminateCtx, synchronizationService, prompter, sessionSelection); terminateErr != nil && err == nil {
+			err = fmt.Errorf("unable to terminate session: %w", terminateErr)
+		}
+	}()
+
+	// Wait for the session to complete a synchronization cycle.
+	if err := synchronizationFlushWithSelection(ctx, synchronizationService, prompter, sessionSelection); err != nil {
+		return fmt.Errorf("unable to flush session: %w", err)
+	}
+
+	// Success.
+	return nil
+}
+
 // synchronizationCreateWithSpecification creates a synchronization session
 // using the provided synchronization service client, session specification, and
 // prompter.
@@ -102,7 +317,7 @@ func synchronizationCreateWithSpecification(
 		Specification: specification,
 	})
 	if err != nil {
-		return "", grpcutil.PeelAwayRPCErrorLayer(err)
+		return "", peelAwayRPCErrorLayer(err)
 	} else if err = response.EnsureValid(); err != nil {
 		return "", fmt.Errorf("invalid create response received: %w", err)
 	}
@@ -189,3 +404,23 @@ func synchronizationTerminateWithSelection(
 	}
 	return nil
 }
+
+// synchronizationResetWithSelection resets synchronization sessions using the
+// provided synchronization service client, session selection, and prompter.
+func synchronizationResetWithSelection(
+	ctx context.Context,
+	synchronizationService synchronizationsvc.SynchronizationClient,
+	prompter string,
+	selection *selection.Selection,
+) error {
+	response, err := synchronizationService.Reset(ctx, &synchronizationsvc.ResetRequest{
+		Prompter:  prompter,
+		Selection: selection,
+	})
+	if err != nil {
+		return grpcutil.PeelAwayRPCErrorLayer(err)
+	} else if err = response.EnsureValid(); err != nil {
+		return fmt.Errorf("invalid reset response received: %w", err)
+	}
+	return nil
+}